@@ -0,0 +1,133 @@
+package cec
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// AudioStatus - volume and mute state of the audio system
+type AudioStatus struct {
+	// Volume - 0 to 100 (percent)
+	Volume int
+	Muted  bool
+	// Known - false if the audio system did not report a volume
+	Known bool
+}
+
+// maximum volume steps SetVolume sends without the volume changing
+const volumeStepAttempts = 3
+
+// DecodeAudioStatus - decode the status byte of Report Audio Status
+// (mute flag in bit 7, volume in bits 0-6)
+func DecodeAudioStatus(status byte) AudioStatus {
+	volume := int(status & audioVolumeStatusMask)
+	return AudioStatus{
+		Volume: volume,
+		Muted:  status&audioMuteStatusMask != 0,
+		Known:  volume <= audioVolumeMax,
+	}
+}
+
+func (s AudioStatus) String() string {
+	if !s.Known {
+		return "Unknown"
+	}
+	if s.Muted {
+		return fmt.Sprintf("%d%% (muted)", s.Volume)
+	}
+	return fmt.Sprintf("%d%%", s.Volume)
+}
+
+// AudioStatus - the decoded status byte
+func (m ReportAudioStatus) AudioStatus() AudioStatus {
+	return DecodeAudioStatus(m.Status)
+}
+
+// GetAudioStatus - the volume and mute state reported by the audio system
+func (c *Connection) GetAudioStatus() AudioStatus {
+	return DecodeAudioStatus(byte(c.backend.GetAudioStatus()))
+}
+
+// RequestAudioStatus - ask the audio system for its volume and mute state
+// with Give Audio Status
+func (c *Connection) RequestAudioStatus(ctx context.Context) (AudioStatus, error) {
+	reply, err := c.requestOnce(ctx, AudioSystem, GiveAudioStatus{})
+	if err != nil {
+		return AudioStatus{}, err
+	}
+	status, ok := reply.(ReportAudioStatus)
+	if !ok {
+		return AudioStatus{}, unexpectedReply(OpGiveAudioStatus, reply)
+	}
+	return status.AudioStatus(), nil
+}
+
+// SetVolume - step the volume of the audio system up or down until it
+// reports the given level (0-100). Audio systems changing the volume by
+// more than one per step may not hit the level exactly, the status
+// closest to it is returned.
+func (c *Connection) SetVolume(ctx context.Context, percent int) (AudioStatus, error) {
+	if percent < 0 || percent > 100 {
+		return AudioStatus{}, fmt.Errorf("cec: invalid volume %d", percent)
+	}
+
+	status, err := c.RequestAudioStatus(ctx)
+	if err != nil {
+		return status, err
+	}
+	if !status.Known {
+		return status, errors.New("cec: volume of the audio system unknown")
+	}
+
+	step := func(up bool) error {
+		if up {
+			return c.VolumeUpContext(ctx)
+		}
+		return c.VolumeDownContext(ctx)
+	}
+
+	stuck := 0
+	for status.Volume != percent {
+		up := status.Volume < percent
+		if err := step(up); err != nil {
+			return status, err
+		}
+
+		next, err := c.RequestAudioStatus(ctx)
+		if err != nil {
+			return status, err
+		}
+		if !next.Known {
+			return next, errors.New("cec: volume of the audio system unknown")
+		}
+
+		switch {
+		case next.Volume == status.Volume:
+			stuck++
+			if stuck >= volumeStepAttempts {
+				return next, fmt.Errorf("cec: volume does not change from %d", next.Volume)
+			}
+		case up != (next.Volume < percent) && next.Volume != percent:
+			// stepped over the level, step back if that was closer
+			if abs(next.Volume-percent) <= abs(status.Volume-percent) {
+				return next, nil
+			}
+			if err := step(!up); err != nil {
+				return next, err
+			}
+			return c.RequestAudioStatus(ctx)
+		default:
+			stuck = 0
+		}
+		status = next
+	}
+	return status, nil
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,129 @@
+package cec_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/chbmuc/cec"
+	"github.com/chbmuc/cec/cectest"
+)
+
+// stepping - an audio system handler changing the volume by step for
+// every volume key
+func stepping(step int) func(d *cectest.Device, frame []byte) ([][]byte, bool) {
+	return func(d *cectest.Device, frame []byte) ([][]byte, bool) {
+		if len(frame) < 3 || frame[1] != 0x44 || (frame[2] != 0x41 && frame[2] != 0x42) {
+			return nil, false
+		}
+		if frame[2] == 0x41 {
+			d.Volume += step
+		} else {
+			d.Volume -= step
+		}
+		return nil, true
+	}
+}
+
+func TestSetVolume(t *testing.T) {
+	tests := []struct {
+		name    string
+		step    int
+		percent int
+		want    int
+		wantErr bool
+	}{
+		{name: "up", step: 1, percent: 25, want: 25},
+		{name: "down", step: 1, percent: 17, want: 17},
+		{name: "unchanged", step: 1, percent: 20, want: 20},
+		// 23 then 26, which is closer to 25 than 23
+		{name: "overshoot", step: 3, percent: 25, want: 26},
+		// 23 is farther from 21 than 20: step back
+		{name: "step back", step: 3, percent: 21, want: 20},
+		{name: "stuck", step: 0, percent: 25, want: 20, wantErr: true},
+		{name: "invalid", step: 1, percent: 101, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			avr := cectest.NewAudioSystem(0x2000)
+			avr.Handler = stepping(tt.step)
+			c, bus := openBus(t, cectest.NewTV(), avr)
+
+			status, err := c.SetVolume(context.Background(), tt.percent)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SetVolume(%d) = %v, %v", tt.percent, status, err)
+			}
+			if tt.percent > 100 {
+				return
+			}
+			if status.Volume != tt.want {
+				t.Errorf("SetVolume(%d) = %v, want %d%%", tt.percent, status, tt.want)
+			}
+			if got := bus.Device(5).Volume; got != tt.want {
+				t.Errorf("volume of the audio system = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+// deaf - a backend whose volume keys do not reach the audio system
+type deaf struct {
+	cec.Backend
+}
+
+func (deaf) VolumeUp() error {
+	return cec.ErrTransmitFailed
+}
+
+func (deaf) VolumeDown() error {
+	return cec.ErrTransmitFailed
+}
+
+func TestSetVolumeTransmitFailed(t *testing.T) {
+	bus := cectest.NewBus(cectest.NewTV(), cectest.NewAudioSystem(0x2000))
+	c, err := cec.OpenBackend(deaf{bus.NewBackend(cectest.NewPlayback(4, 0x1000, "cec.go"))})
+	if err != nil {
+		t.Fatalf("OpenBackend: %v", err)
+	}
+	defer c.Destroy()
+
+	status, err := c.SetVolume(context.Background(), 25)
+	if !errors.Is(err, cec.ErrTransmitFailed) {
+		t.Errorf("SetVolume = %v, %v, want ErrTransmitFailed", status, err)
+	}
+	if status.Volume != 20 {
+		t.Errorf("SetVolume = %v, want the status before the failed step", status)
+	}
+}
+
+func TestSystemAudioMode(t *testing.T) {
+	c, bus := openBus(t, cectest.NewTV(), cectest.NewAudioSystem(0x2000))
+	ctx := context.Background()
+
+	if _, known := c.SystemAudioMode(); known {
+		t.Error("System Audio Mode known before it was seen on the bus")
+	}
+
+	on, err := c.RequestSystemAudioMode(ctx, true)
+	if err != nil || !on {
+		t.Fatalf("RequestSystemAudioMode(true) = %v, %v", on, err)
+	}
+	if !bus.Device(5).SystemAudioMode {
+		t.Error("audio system did not turn System Audio Mode on")
+	}
+	// the audio system broadcasts the mode it set
+	if on, known := c.SystemAudioMode(); !on || !known {
+		t.Errorf("SystemAudioMode = %v, %v, want on", on, known)
+	}
+	if on, err := c.GetSystemAudioModeStatus(ctx); err != nil || !on {
+		t.Errorf("GetSystemAudioModeStatus = %v, %v, want on", on, err)
+	}
+
+	if on, err := c.RequestSystemAudioMode(ctx, false); err != nil || on {
+		t.Errorf("RequestSystemAudioMode(false) = %v, %v", on, err)
+	}
+	if on, known := c.SystemAudioMode(); on || !known {
+		t.Errorf("SystemAudioMode = %v, %v, want off", on, known)
+	}
+}
@@ -0,0 +1,167 @@
+package cec
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// audioModes - System Audio Mode and ARC state tracked from the bus
+type audioModes struct {
+	mu                   sync.Mutex
+	systemAudioMode      bool
+	systemAudioModeKnown bool
+	arc                  bool
+	arcKnown             bool
+}
+
+// SystemAudioMode - whether the audio system plays the audio (speakers of
+// the TV muted), known is false until it was seen on the bus
+func (c *Connection) SystemAudioMode() (on bool, known bool) {
+	c.audio.mu.Lock()
+	defer c.audio.mu.Unlock()
+
+	return c.audio.systemAudioMode, c.audio.systemAudioModeKnown
+}
+
+// ARC - whether the Audio Return Channel is active, known is false until
+// it was seen on the bus
+func (c *Connection) ARC() (active bool, known bool) {
+	c.audio.mu.Lock()
+	defer c.audio.mu.Unlock()
+
+	return c.audio.arc, c.audio.arcKnown
+}
+
+// RequestSystemAudioMode - ask the audio system to turn System Audio Mode
+// on (for the audio of the active source) or off, returns the mode the
+// audio system set
+func (c *Connection) RequestSystemAudioMode(ctx context.Context, on bool) (bool, error) {
+	msg := SystemAudioModeRequest{On: on}
+	if on {
+		msg.Addr = RootPhysicalAddress
+		if source := c.GetActiveSource(); source.IsValid() && source != Broadcast {
+			msg.Addr = c.GetDevicePhysicalAddress(source)
+		}
+	}
+
+	reply, err := c.Request(ctx, AudioSystem, msg)
+	if err != nil {
+		return false, err
+	}
+	mode, ok := reply.(SetSystemAudioMode)
+	if !ok {
+		return false, unexpectedReply(OpSystemAudioModeRequest, reply)
+	}
+	return mode.On, nil
+}
+
+// GetSystemAudioModeStatus - ask the audio system whether System Audio
+// Mode is on
+func (c *Connection) GetSystemAudioModeStatus(ctx context.Context) (bool, error) {
+	reply, err := c.Request(ctx, AudioSystem, GiveSystemAudioModeStatus{})
+	if err != nil {
+		return false, err
+	}
+	status, ok := reply.(SystemAudioModeStatus)
+	if !ok {
+		return false, unexpectedReply(OpGiveSystemAudioModeStatus, reply)
+	}
+	return status.On, nil
+}
+
+// SetSystemAudioMode - announce System Audio Mode as the audio system, to
+// the TV or broadcast (Broadcast) to all devices
+func (c *Connection) SetSystemAudioMode(ctx context.Context, destination LogicalAddress, on bool) error {
+	if err := c.SendContext(ctx, destination, SetSystemAudioMode{On: on}); err != nil {
+		return err
+	}
+	c.setSystemAudioMode(on)
+	return nil
+}
+
+// InitiateARC - start the Audio Return Channel as the audio system, returns
+// whether the TV reported it as started
+func (c *Connection) InitiateARC(ctx context.Context) (bool, error) {
+	reply, err := c.Request(ctx, TV, InitiateARC{})
+	if err != nil {
+		return false, err
+	}
+	return reply.Opcode() == OpReportARCInitiated, nil
+}
+
+// TerminateARC - stop the Audio Return Channel as the audio system
+func (c *Connection) TerminateARC(ctx context.Context) error {
+	_, err := c.Request(ctx, TV, TerminateARC{})
+	return err
+}
+
+// RequestARCInitiation - ask the audio system to start the Audio Return
+// Channel as the TV. The Initiate ARC of the audio system is answered with
+// Report ARC Initiated.
+func (c *Connection) RequestARCInitiation(ctx context.Context) error {
+	if _, err := c.Request(ctx, AudioSystem, RequestARCInitiation{}); err != nil {
+		return err
+	}
+	if err := c.SendContext(ctx, AudioSystem, ReportARCInitiated{}); err != nil {
+		return err
+	}
+	c.setARC(true)
+	return nil
+}
+
+// RequestARCTermination - ask the audio system to stop the Audio Return
+// Channel as the TV. The Terminate ARC of the audio system is answered
+// with Report ARC Terminated.
+func (c *Connection) RequestARCTermination(ctx context.Context) error {
+	if _, err := c.Request(ctx, AudioSystem, RequestARCTermination{}); err != nil {
+		return err
+	}
+	if err := c.SendContext(ctx, AudioSystem, ReportARCTerminated{}); err != nil {
+		return err
+	}
+	c.setARC(false)
+	return nil
+}
+
+// observeAudioModes - track System Audio Mode and ARC from received
+// commands
+func (c *Connection) observeAudioModes(event interface{}) {
+	cmd, ok := event.(Command)
+	if !ok || cmd.Message == nil {
+		return
+	}
+
+	switch m := cmd.Message.(type) {
+	case SetSystemAudioMode:
+		c.setSystemAudioMode(m.On)
+	case SystemAudioModeStatus:
+		c.setSystemAudioMode(m.On)
+	case ReportARCInitiated:
+		c.setARC(true)
+	case ReportARCTerminated:
+		c.setARC(false)
+	}
+}
+
+func (c *Connection) setSystemAudioMode(on bool) {
+	c.audio.mu.Lock()
+	changed := !c.audio.systemAudioModeKnown || c.audio.systemAudioMode != on
+	c.audio.systemAudioMode, c.audio.systemAudioModeKnown = on, true
+	c.audio.mu.Unlock()
+
+	if changed {
+		c.publish(SystemAudioModeChanged{On: on, Timestamp: time.Now()})
+	}
+}
+
+func (c *Connection) setARC(active bool) {
+	c.audio.mu.Lock()
+	changed := !c.audio.arcKnown || c.audio.arc != active
+	c.audio.arc, c.audio.arcKnown = active, true
+	c.audio.mu.Unlock()
+
+	if changed {
+		c.publish(ARCChanged{Active: active, Timestamp: time.Now()})
+	}
+}
@@ -0,0 +1,51 @@
+package cec
+
+// Backend - the transport a Connection talks to. The libcec binding is the
+// default implementation, other transports (or fakes for testing) can be
+// used with OpenBackend.
+type Backend interface {
+	// Transmit sends a raw CEC frame (header, opcode and operands)
+	Transmit(frame []byte) error
+	// Close releases the adapter
+	Close() error
+	// SetEventHandler sets the function received events (LogMessage,
+	// KeyPress, Command, ...) are reported to
+	SetEventHandler(handler func(event interface{}))
+	// LogicalAddress returns the logical address frames are sent from
+	LogicalAddress() int
+
+	PowerOn(address int) error
+	Standby(address int) error
+	VolumeUp() error
+	VolumeDown() error
+	Mute() error
+	KeyPress(address int, key int) error
+	KeyRelease(address int) error
+
+	// GetActiveDevices returns which of the 16 logical addresses are in use
+	GetActiveDevices() [16]bool
+	GetActiveSource() int
+	IsActiveSource(address int) bool
+	PollDevice(address int) bool
+	GetDeviceOSDName(address int) string
+	GetDeviceVendorID(address int) uint64
+	// GetDevicePhysicalAddress returns the physical address as the 16 bit
+	// value used on the bus (e.g. 0x1000 for 1.0.0.0)
+	GetDevicePhysicalAddress(address int) uint16
+	// GetDevicePowerStatus returns the CEC power status code (0x00 on,
+	// 0x01 standby, 0x02 standby to on, 0x03 on to standby, 0x99 unknown)
+	GetDevicePowerStatus(address int) int
+	// GetAudioStatus returns the raw CEC audio status byte
+	GetAudioStatus() int
+}
+
+// EventQueue - implemented by backends that queue events before handing
+// them to the event handler. OpenBackend bounds the queue like the
+// subscriptions' buffers (WithEventBuffer, WithOverflowPolicy).
+type EventQueue interface {
+	// SetEventQueue sets the number of events queued before the policy
+	// applies
+	SetEventQueue(size int, policy OverflowPolicy)
+	// DroppedEvents returns the number of events the queue lost
+	DroppedEvents() uint64
+}
@@ -1,3 +1,5 @@
+//go:build cgo && !nolibcec
+
 package cec
 
 // #include <libcec/cecc.h>
@@ -8,23 +10,6 @@ import (
 	"unsafe"
 )
 
-type LogicalAddress struct {
-	LogicalAddress int
-	Type           string
-}
-
-func NewLogicalAddress(address C.cec_logical_address) LogicalAddress {
-	return LogicalAddress{LogicalAddress: int(address), Type: GetLogicalNameByAddress(int(address))}
-}
-
-type LogMessage struct {
-	Message                     string
-	Level                       string
-	Direction                   string
-	MillisecondsSinceConnection int64
-	Timestamp                   time.Time
-}
-
 //export logMessageCallback
 func logMessageCallback(c unsafe.Pointer, msg C.cec_log_message) C.uint8_t {
 	var level string
@@ -66,61 +51,37 @@ func logMessageCallback(c unsafe.Pointer, msg C.cec_log_message) C.uint8_t {
 		MillisecondsSinceConnection: int64(msg.time),
 		Timestamp:                   time.Now(),
 	}
-	CallbackEvents <- message
+	backendFromParam(c).emit(message)
 
 	return 1
 }
 
-type KeyPress struct {
-	KeyCode     int
-	KeyCodeName string
-	Duration    int
-	Timestamp   time.Time
-}
-
 //export keyPressCallback
 func keyPressCallback(c unsafe.Pointer, keyPress C.cec_keypress) C.uint8_t {
-	CallbackEvents <- KeyPress{
-		KeyCode:     int(keyPress.keycode),
-		KeyCodeName: GetUserControlKeyString(keyPress.keycode),
+	backendFromParam(c).emit(KeyPress{
+		KeyCode:     UserControlCode(keyPress.keycode),
+		KeyCodeName: UserControlCode(keyPress.keycode).String(),
 		Duration:    int(keyPress.duration),
 		Timestamp:   time.Now(),
-	}
+	})
 	return 1
 }
 
-type DataPacket struct {
-	Data interface{}
-	Size int
-}
-
-type Command struct {
-	Initiator       LogicalAddress
-	Destination     LogicalAddress
-	Acknowledged    bool
-	EndOfMessage    bool
-	Opcode          int
-	OpcodeName      string
-	Parameters      DataPacket
-	OpcodeSet       bool
-	TransmitTimeout int32
-	Timestamp       time.Time
-}
-
 //export commandCallback
 func commandCallback(c unsafe.Pointer, command C.cec_command) C.uint8_t {
-	CallbackEvents <- Command{
-		Initiator:       NewLogicalAddress(command.initiator),
-		Destination:     NewLogicalAddress(command.destination),
-		Acknowledged:    (int(command.ack) == 1),
-		EndOfMessage:    (int(command.eom) == 1),
-		Opcode:          int(command.opcode),
-		OpcodeName:      GetOpcodeString(int(command.opcode)),
-		Parameters:      DataPacket{Data: command.parameters.data, Size: int(command.parameters.size)},
-		OpcodeSet:       (int(command.opcode_set) == 1),
-		TransmitTimeout: int32(command.transmit_timeout),
-		Timestamp:       time.Now(),
+	frame := []byte{byte(command.initiator)<<4 | byte(command.destination)&0xF}
+	if int(command.opcode_set) == 1 {
+		frame = append(frame, byte(command.opcode))
+		for i := 0; i < int(command.parameters.size) && i < len(command.parameters.data); i++ {
+			frame = append(frame, byte(command.parameters.data[i]))
+		}
 	}
+
+	event := NewCommand(frame)
+	event.Acknowledged = (int(command.ack) == 1)
+	event.EndOfMessage = (int(command.eom) == 1)
+	event.TransmitTimeout = int32(command.transmit_timeout)
+	backendFromParam(c).emit(event)
 	return 1
 }
 
@@ -129,17 +90,6 @@ func configurationChangedCallback(c unsafe.Pointer, configuration C.libcec_confi
 	return 1
 }
 
-type Parameter struct {
-	Type string
-	Data interface{}
-}
-
-type Alert struct {
-	Type       string
-	Parameters Parameter
-	Timestamp  time.Time
-}
-
 //export alertCallback
 func alertCallback(c unsafe.Pointer, alert C.libcec_alert, parameter C.libcec_parameter) C.uint8_t {
 	var parameterType string
@@ -156,6 +106,10 @@ func alertCallback(c unsafe.Pointer, alert C.libcec_alert, parameter C.libcec_pa
 		alertType = "SERVICE_DEVICE"
 	case C.CEC_ALERT_CONNECTION_LOST:
 		alertType = "CONNECTION_LOST"
+		backend := backendFromParam(c)
+		backend.mu.Lock()
+		backend.lost = true
+		backend.mu.Unlock()
 	case C.CEC_ALERT_PERMISSION_ERROR:
 		alertType = "PERMISSION_ERROR"
 	case C.CEC_ALERT_PORT_BUSY:
@@ -166,43 +120,33 @@ func alertCallback(c unsafe.Pointer, alert C.libcec_alert, parameter C.libcec_pa
 		alertType = "TV_POLL_FAILED"
 	}
 
-	CallbackEvents <- Alert{
+	backendFromParam(c).emit(Alert{
 		Type: alertType,
 		Parameters: Parameter{
 			Type: parameterType,
 			Data: parameter.paramData,
 		},
 		Timestamp: time.Now(),
-	}
+	})
 	return 1
 }
 
-type MenuState struct {
-	Activated bool
-	Timestamp time.Time
-}
-
 // menuState is bool, 0 = activated, 1 = deactivated
+//
 //export menuStateChangedCallback
 func menuStateChangedCallback(c unsafe.Pointer, state C.cec_menu_state) C.uint8_t {
-	CallbackEvents <- MenuState{
+	backendFromParam(c).emit(MenuState{
 		Activated: int(state) == 0,
 		Timestamp: time.Now(),
-	}
+	})
 	return 1
 }
 
-type SourceActivated struct {
-	Source    LogicalAddress
-	Active    bool
-	Timestamp time.Time
-}
-
 //export sourceActivatedCallback
 func sourceActivatedCallback(c unsafe.Pointer, logicalAddress C.cec_logical_address, activated int) {
-	CallbackEvents <- SourceActivated{
-		Source:    NewLogicalAddress(logicalAddress),
+	backendFromParam(c).emit(SourceActivated{
+		Source:    LogicalAddress(logicalAddress),
 		Active:    (activated == 1),
 		Timestamp: time.Now(),
-	}
+	})
 }
@@ -1,10 +1,12 @@
 package cec
 
 import (
+	"context"
 	"encoding/hex"
 	"errors"
-	"log"
+	"fmt"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -12,12 +14,15 @@ import (
 type Device struct {
 	OSDName            string
 	Vendor             string
-	LogicalAddress     int
+	VendorID           uint64
+	LogicalAddress     LogicalAddress
 	LogicalAddressName string
 	ActiveSource       bool
-	PowerStatus        string
-	PhysicalAddress    string
+	PowerStatus        PowerStatus
+	PhysicalAddress    PhysicalAddress
 	RoomieName         string
+	CECVersion         byte
+	MenuLanguage       string
 }
 
 var logicalNames = []string{"TV", "Recording", "Recording2", "Tuner",
@@ -25,7 +30,7 @@ var logicalNames = []string{"TV", "Recording", "Recording2", "Tuner",
 	"Playback2", "Recording3", "Tuner4", "Playback3",
 	"Reserved", "Reserved2", "Free", "Broadcast"}
 
-var keyList = map[int]string{0x00: "Select", 0x01: "Up", 0x02: "Down", 0x03: "Left",
+var keyList = map[UserControlCode]string{0x00: "Select", 0x01: "Up", 0x02: "Down", 0x03: "Left",
 	0x04: "Right", 0x05: "RightUp", 0x06: "RightDown", 0x07: "LeftUp",
 	0x08: "LeftDown", 0x09: "RootMenu", 0x0A: "SetupMenu", 0x0B: "ContentsMenu",
 	0x0C: "FavoriteMenu", 0x0D: "Exit", 0x20: "0", 0x21: "1", 0x22: "2", 0x23: "3",
@@ -41,103 +46,274 @@ var keyList = map[int]string{0x00: "Select", 0x01: "Up", 0x02: "Down", 0x03: "Le
 	0x53: "ElectronicProgramGuide", 0x54: "TimerProgramming",
 	0x55: "InitialConfiguration", 0x60: "PlayFunction", 0x61: "PausePlay",
 	0x62: "RecordFunction", 0x63: "PauseRecordFunction",
-	0x64: "StopFunction", 0x65: "Mute",
+	0x64: "StopFunction", 0x65: "MuteFunction",
 	0x66: "RestoreVolume", 0x67: "Tune", 0x68: "SelectMedia",
 	0x69: "SelectAvInput", 0x6A: "SelectAudioInput", 0x6B: "PowerToggle",
-	0x6C: "PowerOff", 0x6D: "PowerOn", 0x71: "Blue", 0X72: "Red", 0x73: "Green",
+	0x6C: "PowerOff", 0x6D: "PowerOn", 0x71: "Blue", 0x72: "Red", 0x73: "Green",
 	0x74: "Yellow", 0x75: "F5", 0x76: "Data", 0x91: "AnReturn",
 	0x96: "Max"}
 
-// Open - open a new connection to the CEC device with the given name
-func Open(name, deviceName, deviceType string) (*Connection, error) {
-	c := new(Connection)
+// Connection class
+type Connection struct {
+	backend  Backend
+	options  options
+	registry *DeviceRegistry
+	audio    audioModes
 
-	var err error
+	mu      sync.Mutex
+	own     LogicalAddress // see cachedAddress
+	subs    []*Subscription
+	dropped uint64        // by subscriptions that are gone
+	stream  *Subscription // feeds events, see Events
+	events  chan interface{}
+	done    chan struct{}
+	closed  bool
+	polling chan struct{}  // closed when the device polling stopped
+	calls   sync.WaitGroup // backend calls made through do
+}
 
-	c.connection, err = cecInit(deviceName, deviceType)
+// Open - open a new connection to the CEC device with the given name
+func Open(name, deviceName string, deviceType DeviceType, opts ...Option) (*Connection, error) {
+	if !deviceType.IsValid() {
+		return nil, fmt.Errorf("Invalid device type %d", deviceType)
+	}
+	backend, err := openLibcec(name, deviceName, deviceType)
 	if err != nil {
-		log.Println(err)
 		return nil, err
 	}
 
-	adapter, err := getAdapter(c.connection, name)
-	if err != nil {
-		log.Println(err)
-		return nil, err
+	return OpenBackend(backend, opts...)
+}
+
+// OpenBackend - open a new connection on top of an already opened backend
+func OpenBackend(backend Backend, opts ...Option) (*Connection, error) {
+	if backend == nil {
+		return nil, errors.New("No backend given")
 	}
 
-	err = openAdapter(c.connection, adapter)
-	if err != nil {
-		log.Println(err)
-		return nil, err
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(&o)
 	}
 
+	c := &Connection{
+		backend: backend,
+		options: o,
+		events:  make(chan interface{}),
+		done:    make(chan struct{}),
+	}
+	c.own = LogicalAddress(backend.LogicalAddress())
+	c.registry = newDeviceRegistry(c, o.deviceTTL)
+	if q, ok := backend.(EventQueue); ok {
+		q.SetEventQueue(o.eventBufferSize, o.overflowPolicy)
+	}
+	backend.SetEventHandler(c.publish)
+
 	c.GetActiveSource()
 
+	if o.pollInterval > 0 {
+		c.polling = make(chan struct{})
+		go c.registry.poll(o.pollInterval, c.polling)
+	}
+
 	return c, nil
 }
 
+// Backend - returns the backend the connection talks to
+func (c *Connection) Backend() Backend {
+	return c.backend
+}
+
+// Transmit CEC command - command is encoded as a hex string with
+// colons (e.g. "40:04")
+func (c *Connection) Transmit(command string) error {
+	cmd, err := hex.DecodeString(removeSeparators(command))
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidFrame, err)
+	}
+	if len(cmd) == 0 || len(cmd) > 16 {
+		return fmt.Errorf("%w: %d bytes", ErrInvalidFrame, len(cmd))
+	}
+
+	return c.backend.Transmit(cmd)
+}
+
+// Send - encode a message and transmit it to the device with the given
+// logical address (0xF for broadcast)
+func (c *Connection) Send(destination LogicalAddress, msg Message) error {
+	frame, err := Encode(LogicalAddress(c.backend.LogicalAddress()), destination, msg)
+	if err != nil {
+		return err
+	}
+
+	return c.backend.Transmit(frame)
+}
+
+// Destroy - destroy the cec connection
+func (c *Connection) Destroy() {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return
+	}
+	c.closed = true
+	close(c.done)
+	stream := c.stream
+	subs := c.subs
+	c.subs = nil
+	for _, s := range subs {
+		c.dropped += s.Dropped()
+	}
+	c.mu.Unlock()
+
+	// release callbacks blocked on a full buffer before the backend waits
+	// for them
+	for _, s := range subs {
+		s.buffer.close()
+	}
+	if c.polling != nil {
+		<-c.polling
+	}
+	c.calls.Wait()
+	c.backend.Close()
+	if stream != nil {
+		<-stream.stopped
+	}
+	close(c.events)
+}
+
+// PowerOn - power on the device with the given logical address
+func (c *Connection) PowerOn(address LogicalAddress) error {
+	return c.backend.PowerOn(int(address))
+}
+
+// Standby - put the device with the given address in standby mode
+func (c *Connection) Standby(address LogicalAddress) error {
+	return c.backend.Standby(int(address))
+}
+
+// VolumeUp - send a volume up command to the amp if present
+func (c *Connection) VolumeUp() error {
+	return c.backend.VolumeUp()
+}
+
+// VolumeDown - send a volume down command to the amp if present
+func (c *Connection) VolumeDown() error {
+	return c.backend.VolumeDown()
+}
+
+// Mute - send a mute/unmute command to the amp if present
+func (c *Connection) Mute() error {
+	return c.backend.Mute()
+}
+
+// KeyPress - send a key press (down) command code to the given address
+func (c *Connection) KeyPress(address LogicalAddress, key UserControlCode) error {
+	return c.backend.KeyPress(int(address), int(key))
+}
+
+// KeyRelease - send a key releas command to the given address
+func (c *Connection) KeyRelease(address LogicalAddress) error {
+	return c.backend.KeyRelease(int(address))
+}
+
+// GetActiveDevices - returns an array of active devices
+func (c *Connection) GetActiveDevices() [16]bool {
+	return c.backend.GetActiveDevices()
+}
+
+// GetActiveSource - returns the logical address of the currently active source
+func (c *Connection) GetActiveSource() LogicalAddress {
+	return LogicalAddress(c.backend.GetActiveSource())
+}
+
+// GetDeviceOSDName - get the OSD name of the specified device
+func (c *Connection) GetDeviceOSDName(address LogicalAddress) string {
+	return c.backend.GetDeviceOSDName(int(address))
+}
+
+// IsActiveSource - check if the device at the given address is the active source
+func (c *Connection) IsActiveSource(address LogicalAddress) bool {
+	return c.backend.IsActiveSource(int(address))
+}
+
+// GetDeviceVendorID - Get the Vendor-ID of the device at the given address
+func (c *Connection) GetDeviceVendorID(address LogicalAddress) uint64 {
+	return c.backend.GetDeviceVendorID(int(address))
+}
+
+// GetDevicePhysicalAddress - Get the physical address of the device at
+// the given logical address
+func (c *Connection) GetDevicePhysicalAddress(address LogicalAddress) PhysicalAddress {
+	return PhysicalAddress(c.backend.GetDevicePhysicalAddress(int(address)))
+}
+
+// GetDevicePowerStatus - Get the power status of the device at the
+// given address, PowerStatusUnknown if it did not answer
+func (c *Connection) GetDevicePowerStatus(address LogicalAddress) PowerStatus {
+	result := PowerStatus(c.backend.GetDevicePowerStatus(int(address)))
+	if !result.IsValid() {
+		return PowerStatusUnknown
+	}
+	return result
+}
+
+func (c *Connection) PollDevice(address LogicalAddress) bool {
+	return c.backend.PollDevice(int(address))
+}
+
 // Key - send key press and release commands (hold key for 10ms) to the device
 // at the given address, the key code can be specified as a hex-code or by
 // its name
-func (c *Connection) Key(address int, key interface{}) error {
+func (c *Connection) Key(address LogicalAddress, key interface{}) error {
+	keycode, err := parseKey(key)
+	if err != nil {
+		return err
+	}
+	err = c.KeyPress(address, keycode)
+	if err != nil {
+		return err
+	}
+	time.Sleep(10 * time.Millisecond)
+	return c.KeyRelease(address)
+}
+
+// parseKey - the key code of a hex-code ("0x44"), key name, int or
+// UserControlCode
+func parseKey(key interface{}) (UserControlCode, error) {
 	var keycode int
 
 	switch key := key.(type) {
 	case string:
-		if key[:2] == "0x" && len(key) == 4 {
+		if len(key) == 4 && key[:2] == "0x" {
 			keybytes, err := hex.DecodeString(key[2:])
 			if err != nil {
-				log.Println(err)
-				return err
+				return 0, fmt.Errorf("Invalid key code %q: %v", key, err)
 			}
 			keycode = int(keybytes[0])
 		} else {
 			keycode = GetKeyCodeByName(key)
+			if keycode < 0 {
+				return 0, fmt.Errorf("Unknown key %q", key)
+			}
 		}
 	case int:
 		keycode = key
+	case UserControlCode:
+		return key, nil
 	default:
-		log.Println("Invalid key type")
-		return errors.New("Invalid key type")
+		return 0, errors.New("Invalid key type")
 	}
-	er := c.KeyPress(address, keycode)
-	if er != nil {
-		log.Println(er)
-		return er
+	if keycode < 0 || keycode > 0xFF {
+		return 0, fmt.Errorf("Invalid key code %d", keycode)
 	}
-	time.Sleep(10 * time.Millisecond)
-	er = c.KeyRelease(address)
-	if er != nil {
-		log.Println(er)
-		return er
-	}
-	return nil
+	return UserControlCode(keycode), nil
 }
 
-// List - list active devices (returns a map of Devices)
+// List - list active devices (returns a map of Devices). Devices are
+// answered from the cache, see DeviceRegistry.
 func (c *Connection) List() map[string]Device {
-	devices := make(map[string]Device)
-
-	activeDevices := c.GetActiveDevices()
-
-	for address, active := range activeDevices {
-		if active {
-			var dev Device
-
-			dev.LogicalAddress = address
-			dev.LogicalAddressName = GetLogicalNameByAddress(address)
-			dev.PhysicalAddress = c.GetDevicePhysicalAddress(address)
-			dev.RoomieName = "INPUT HDMI " + dev.PhysicalAddress[0:1]
-			dev.PhysicalAddress = c.GetDevicePhysicalAddress(address)
-			dev.OSDName = c.GetDeviceOSDName(address)
-			dev.PowerStatus = c.GetDevicePowerStatus(address)
-			dev.ActiveSource = c.IsActiveSource(address)
-			dev.Vendor = GetVendorString(c.GetDeviceVendorID(address))
-
-			devices[removeSeparators(dev.LogicalAddressName)] = dev
-		}
-	}
+	devices, _ := c.registry.List(context.Background())
 	return devices
 }
 
@@ -160,7 +336,7 @@ func GetKeyCodeByName(name string) int {
 
 	for code, value := range keyList {
 		if strings.ToLower(value) == name {
-			return code
+			return int(code)
 		}
 	}
 
@@ -171,6 +347,9 @@ func GetKeyCodeByName(name string) int {
 func GetLogicalAddressByName(name string) int {
 	name = removeSeparators(name)
 	l := len(name)
+	if l == 0 {
+		return -1
+	}
 
 	if name[l-1] == '1' {
 		name = name[:l-1]
@@ -190,3 +369,28 @@ func GetLogicalAddressByName(name string) int {
 
 	return -1
 }
+
+// ownAddress - the logical address of the connection, asks the backend
+// and refreshes the cached copy
+func (c *Connection) ownAddress() LogicalAddress {
+	own := LogicalAddress(c.backend.LogicalAddress())
+	c.setOwnAddress(own)
+	return own
+}
+
+// cachedAddress - the logical address of the connection as of the last
+// ownAddress or SourceActivated event. Event handling uses it instead of
+// asking the backend: libcec calls the event handler from its own thread,
+// which must not call back into libcec.
+func (c *Connection) cachedAddress() LogicalAddress {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.own
+}
+
+func (c *Connection) setOwnAddress(own LogicalAddress) {
+	c.mu.Lock()
+	c.own = own
+	c.mu.Unlock()
+}
@@ -0,0 +1,94 @@
+package cectest
+
+import (
+	"fmt"
+
+	"github.com/chbmuc/cec"
+	"github.com/chbmuc/cec/internal/node"
+)
+
+// Backend - the local node on a simulated bus, implements cec.Backend.
+// It is built like the linuxcec and pulse8 backends: the local device
+// answers the standard requests (OSD name, physical address, ...) the
+// way an adapter does, everything else is left to the shared node, which
+// reports every frame it receives as callback events.
+type Backend struct {
+	*node.Node
+
+	bus    *Bus
+	device *Device
+	ctl    *node.Control
+}
+
+// NewBackend - attach a local node for the given device to the bus. The
+// node answers requests with the device's state.
+func (b *Bus) NewBackend(d *Device) *Backend {
+	backend := &Backend{bus: b, device: d}
+	backend.Node, backend.ctl = node.New(port{bus: b})
+	backend.ctl.SetAddresses(d.LogicalAddress, d.PhysicalAddress)
+	backend.ctl.SetOSDName(d.OSDName)
+	backend.ctl.SetVendorID(uint32(d.VendorID))
+
+	b.mu.Lock()
+	d.bus = b
+	b.devices[d.LogicalAddress&0xF] = d
+	b.backends[d.LogicalAddress&0xF] = backend
+	b.mu.Unlock()
+
+	return backend
+}
+
+// Device - the device the node represents
+func (n *Backend) Device() *Device {
+	return n.device
+}
+
+// Close - detach the node from the bus
+func (n *Backend) Close() error {
+	n.bus.Remove(n.device.LogicalAddress)
+	n.ctl.Stop()
+	return nil
+}
+
+// receive - called by the bus (without the bus lock) for every frame
+// addressed to the node
+func (n *Backend) receive(frame []byte) {
+	n.ctl.Traffic(frame, false)
+	n.ctl.Handle(frame)
+}
+
+// port - the transport of a node, puts its frames on the bus
+type port struct {
+	bus *Bus
+}
+
+func (p port) Transmit(frame []byte) error {
+	_, err := p.bus.send(frame)
+	return err
+}
+
+// Request - the replies to a frame are on the bus by the time it is sent,
+// a request that is not answered fails right away
+func (p port) Request(frame []byte, reply byte) ([]byte, error) {
+	if len(frame) < 2 {
+		return nil, fmt.Errorf("cectest: %w: request without opcode", cec.ErrInvalidFrame)
+	}
+	sent, err := p.bus.send(frame)
+	if err != nil {
+		return nil, err
+	}
+
+	from := frame[0] & 0xF
+	for _, f := range sent[1:] {
+		if len(f) < 2 || f[0]>>4 != from {
+			continue
+		}
+		switch {
+		case f[1] == reply:
+			return f[2:], nil
+		case f[1] == 0x00 && len(f) >= 4 && f[2] == frame[1]: // feature abort
+			return nil, &cec.FeatureAbortError{Opcode: cec.Opcode(f[2]), Reason: f[3]}
+		}
+	}
+	return nil, fmt.Errorf("cectest: no reply: %w", cec.ErrTimeout)
+}
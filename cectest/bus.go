@@ -0,0 +1,194 @@
+// Package cectest provides an in-memory CEC bus with simulated devices, so
+// code built on the cec package can be tested without an adapter or a TV.
+//
+//	bus := cectest.NewBus(cectest.NewTV(), cectest.NewPlayback(4, 0x1000, "Player"))
+//	conn, err := cec.OpenBackend(bus.NewBackend(cectest.NewPlayback(8, 0x2000, "cec.go")))
+package cectest
+
+import (
+	"sync"
+
+	"github.com/chbmuc/cec"
+)
+
+// Bus - a simulated CEC bus. Frames are delivered synchronously: by the
+// time Transmit returns, every device has seen the frame and all replies
+// have been delivered as well.
+type Bus struct {
+	mu           sync.Mutex
+	devices      [16]*Device
+	backends     map[int]*Backend
+	activeSource int
+	frames       [][]byte
+}
+
+// NewBus - create a bus with the given devices attached
+func NewBus(devices ...*Device) *Bus {
+	b := &Bus{backends: make(map[int]*Backend), activeSource: -1}
+	for _, d := range devices {
+		b.Add(d)
+	}
+	return b
+}
+
+// Add - attach a device to the bus, replacing any device at the same
+// logical address
+func (b *Bus) Add(d *Device) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	d.bus = b
+	b.devices[d.LogicalAddress&0xF] = d
+}
+
+// Remove - detach the device at the given logical address
+func (b *Bus) Remove(address int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.devices[address&0xF] = nil
+	delete(b.backends, address&0xF)
+	if b.activeSource == address {
+		b.activeSource = -1
+	}
+}
+
+// Device - returns the device at the given logical address, or nil
+func (b *Bus) Device(address int) *Device {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.devices[address&0xF]
+}
+
+// Update - change the state of an attached device under the bus lock
+func (b *Bus) Update(address int, fn func(d *Device)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if d := b.devices[address&0xF]; d != nil {
+		fn(d)
+	}
+}
+
+// ActiveSource - the logical address of the active source, or -1
+func (b *Bus) ActiveSource() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.activeSource
+}
+
+// Frames - returns a copy of all frames sent on the bus so far
+func (b *Bus) Frames() [][]byte {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	frames := make([][]byte, len(b.frames))
+	for i, f := range b.frames {
+		frames[i] = append([]byte(nil), f...)
+	}
+	return frames
+}
+
+// Transmit - send a raw frame on the bus, e.g. to simulate a device
+// sending a command. Returns an error if a directly addressed frame is
+// not acknowledged.
+func (b *Bus) Transmit(frame []byte) error {
+	_, err := b.send(frame)
+	return err
+}
+
+// delivery - a frame for the node of a local device
+type delivery struct {
+	backend *Backend
+	frame   []byte
+}
+
+// send - transmit a frame and hand what reached the local nodes to them
+// once the lock is released, so they can send in turn. Returns the frame
+// and the replies it caused.
+func (b *Bus) send(frame []byte) ([][]byte, error) {
+	b.mu.Lock()
+	sent, deliveries, err := b.transmit(frame)
+	b.mu.Unlock()
+
+	for _, d := range deliveries {
+		d.backend.receive(d.frame)
+	}
+	return sent, err
+}
+
+// transmit - deliver a frame and all the replies it causes to the
+// simulated devices, must be called with the lock held
+func (b *Bus) transmit(frame []byte) ([][]byte, []delivery, error) {
+	if len(frame) == 0 {
+		return nil, nil, cec.ErrInvalidFrame
+	}
+
+	destination := int(frame[0] & 0xF)
+	if destination != 0xF && b.devices[destination] == nil {
+		f := append([]byte(nil), frame...)
+		b.frames = append(b.frames, f)
+		return [][]byte{f}, nil, cec.ErrNotAcknowledged
+	}
+
+	var sent [][]byte
+	var deliveries []delivery
+	queue := [][]byte{frame}
+	for len(queue) > 0 {
+		f := append([]byte(nil), queue[0]...)
+		queue = queue[1:]
+
+		b.frames = append(b.frames, f)
+		sent = append(sent, f)
+		b.track(f)
+
+		initiator := int(f[0] >> 4)
+		destination := int(f[0] & 0xF)
+		for address, d := range b.devices {
+			if d == nil || address == initiator {
+				continue
+			}
+			if destination != 0xF && destination != address {
+				continue
+			}
+			if backend, ok := b.backends[address]; ok {
+				deliveries = append(deliveries, delivery{backend: backend, frame: f})
+				if answeredByNode(f) {
+					continue
+				}
+			}
+			for _, reply := range d.handle(f) {
+				if to := int(reply[0] & 0xF); to == 0xF || b.devices[to] != nil {
+					queue = append(queue, reply)
+				}
+			}
+		}
+	}
+
+	return sent, deliveries, nil
+}
+
+// answeredByNode - requests the node of a local device answers itself,
+// its device leaves them alone
+func answeredByNode(frame []byte) bool {
+	return len(frame) >= 2 && (frame[1] == 0x8F || frame[1] == 0x86)
+}
+
+// track - keep the bus wide state up to date
+func (b *Bus) track(frame []byte) {
+	if len(frame) < 2 {
+		return
+	}
+
+	initiator := int(frame[0] >> 4)
+	switch frame[1] {
+	case 0x82: // active source
+		b.activeSource = initiator
+	case 0x9D: // inactive source
+		if b.activeSource == initiator {
+			b.activeSource = -1
+		}
+	}
+}
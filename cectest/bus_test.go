@@ -0,0 +1,243 @@
+package cectest
+
+import (
+	"bytes"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/chbmuc/cec"
+)
+
+// recorder - collects the events of a backend
+type recorder struct {
+	mu     sync.Mutex
+	events []interface{}
+}
+
+func (r *recorder) handle(event interface{}) {
+	r.mu.Lock()
+	r.events = append(r.events, event)
+	r.mu.Unlock()
+}
+
+// commands - waits briefly for the events to be delivered and returns the
+// received (inbound) frames
+func (r *recorder) commands(t *testing.T, want int) [][]byte {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		var frames [][]byte
+		r.mu.Lock()
+		for _, e := range r.events {
+			if cmd, ok := e.(cec.Command); ok {
+				frames = append(frames, commandFrame(cmd))
+			}
+		}
+		r.mu.Unlock()
+		if len(frames) >= want || time.Now().After(deadline) {
+			return frames
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func commandFrame(cmd cec.Command) []byte {
+	frame := []byte{byte(cmd.Initiator)<<4 | byte(cmd.Destination)}
+	if cmd.OpcodeSet {
+		frame = append(frame, byte(cmd.Opcode))
+		if params, ok := cmd.Parameters.Data.([]byte); ok {
+			frame = append(frame, params...)
+		}
+	}
+	return frame
+}
+
+func listen(backend *Backend) *recorder {
+	r := &recorder{}
+	backend.SetEventHandler(r.handle)
+	return r
+}
+
+func TestBusDelivery(t *testing.T) {
+	bus := NewBus(NewTV(), NewAudioSystem(0x1000))
+	player := bus.NewBackend(NewPlayback(4, 0x2000, "Player"))
+	defer player.Close()
+	events := listen(player)
+
+	// directly addressed to the TV, the player does not see it
+	if err := bus.Transmit([]byte{0x50, 0x8F}); err != nil {
+		t.Fatalf("transmit to TV: %v", err)
+	}
+	// broadcast, delivered to everybody but the initiator
+	if err := bus.Transmit([]byte{0x5F, 0x82, 0x10, 0x00}); err != nil {
+		t.Fatalf("broadcast: %v", err)
+	}
+	// addressed to the player
+	if err := bus.Transmit([]byte{0x04, 0x46}); err != nil {
+		t.Fatalf("transmit to player: %v", err)
+	}
+
+	got := events.commands(t, 2)
+	want := [][]byte{{0x5F, 0x82, 0x10, 0x00}, {0x04, 0x46}}
+	if len(got) != len(want) {
+		t.Fatalf("player received % x, want % x", got, want)
+	}
+	for i := range want {
+		if !bytes.Equal(got[i], want[i]) {
+			t.Errorf("frame %d = % x, want % x", i, got[i], want[i])
+		}
+	}
+
+	// the TV replied to Give Device Power Status, the player answered Give
+	// OSD Name like libcec does
+	frames := bus.Frames()
+	if !containsFrame(frames, []byte{0x05, 0x90, PowerStandby}) {
+		t.Errorf("no power status reply in % x", frames)
+	}
+	if !containsFrame(frames, append([]byte{0x40, 0x47}, "Player"...)) {
+		t.Errorf("no OSD name reply in % x", frames)
+	}
+	if got := bus.ActiveSource(); got != 5 {
+		t.Errorf("active source = %d, want 5", got)
+	}
+}
+
+func TestBusAcknowledge(t *testing.T) {
+	bus := NewBus(NewTV())
+
+	if err := bus.Transmit([]byte{0x40}); err != nil {
+		t.Errorf("poll of the TV: %v, want acknowledged", err)
+	}
+	if err := bus.Transmit([]byte{0x05}); !errors.Is(err, cec.ErrNotAcknowledged) {
+		t.Errorf("poll of a missing device: %v, want ErrNotAcknowledged", err)
+	}
+	if err := bus.Transmit([]byte{0x05, 0x8F}); !errors.Is(err, cec.ErrNotAcknowledged) {
+		t.Errorf("message to a missing device: %v, want ErrNotAcknowledged", err)
+	}
+	if err := bus.Transmit([]byte{0x0F, 0x36}); err != nil {
+		t.Errorf("broadcast: %v, broadcasts are never refused", err)
+	}
+	if err := bus.Transmit(nil); !errors.Is(err, cec.ErrInvalidFrame) {
+		t.Errorf("empty frame: %v, want ErrInvalidFrame", err)
+	}
+
+	// a not acknowledged frame is still on the bus
+	frames := bus.Frames()
+	if !containsFrame(frames, []byte{0x05, 0x8F}) {
+		t.Errorf("not acknowledged frame missing from % x", frames)
+	}
+}
+
+func TestBusRemove(t *testing.T) {
+	bus := NewBus(NewTV())
+	player := bus.NewBackend(NewPlayback(4, 0x1000, "Player"))
+	events := listen(player)
+
+	if err := bus.Transmit([]byte{0x4F, 0x82, 0x10, 0x00}); err != nil {
+		t.Fatalf("active source: %v", err)
+	}
+	if got := bus.ActiveSource(); got != 4 {
+		t.Fatalf("active source = %d, want 4", got)
+	}
+
+	bus.Remove(4)
+	if bus.Device(4) != nil {
+		t.Error("device still attached after Remove")
+	}
+	if got := bus.ActiveSource(); got != -1 {
+		t.Errorf("active source = %d after Remove, want -1", got)
+	}
+	if err := bus.Transmit([]byte{0x04, 0x46}); !errors.Is(err, cec.ErrNotAcknowledged) {
+		t.Errorf("message to a removed device: %v, want ErrNotAcknowledged", err)
+	}
+	if err := bus.Transmit([]byte{0x0F, 0x85}); err != nil {
+		t.Fatalf("broadcast: %v", err)
+	}
+	// the backend of the removed device gets no more frames
+	time.Sleep(20 * time.Millisecond)
+	for _, f := range events.commands(t, 0) {
+		if f[0] == 0x04 || f[0] == 0x0F {
+			t.Errorf("removed device received % x", f)
+		}
+	}
+	player.Close()
+}
+
+func TestBackendRequests(t *testing.T) {
+	bus := NewBus(NewTV(), NewAudioSystem(0x1000))
+	player := bus.NewBackend(NewPlayback(4, 0x2000, "Player"))
+	defer player.Close()
+
+	if got := player.GetDeviceOSDName(0); got != "TV" {
+		t.Errorf("OSD name = %q, want TV", got)
+	}
+	if got := player.GetDeviceVendorID(0); got != 0x0000F0 {
+		t.Errorf("vendor ID = %06x, want 0000f0", got)
+	}
+	if got := player.GetDevicePhysicalAddress(5); got != 0x1000 {
+		t.Errorf("physical address = %04x, want 1000", got)
+	}
+	if got := player.GetDevicePowerStatus(3); got != 0x99 {
+		t.Errorf("power status of a missing device = %02x, want 99", got)
+	}
+	if !player.PollDevice(5) || player.PollDevice(3) {
+		t.Error("PollDevice does not match the attached devices")
+	}
+
+	if err := player.PowerOn(0); err != nil {
+		t.Fatalf("power on: %v", err)
+	}
+	if got := bus.Device(0).PowerStatus; got != PowerOn {
+		t.Errorf("TV power status = %d after PowerOn, want on", got)
+	}
+
+	if err := player.VolumeUp(); err != nil {
+		t.Fatalf("volume up: %v", err)
+	}
+	if got := bus.Device(5).Volume; got != 21 {
+		t.Errorf("volume = %d, want 21", got)
+	}
+	if got := player.GetAudioStatus(); got != 21 {
+		t.Errorf("audio status = %02x, want 21", got)
+	}
+}
+
+func TestBackendAnswers(t *testing.T) {
+	bus := NewBus(NewTV())
+	player := bus.NewBackend(NewPlayback(4, 0x2000, "Player"))
+	defer player.Close()
+
+	// the node answers these itself, the device must not answer as well
+	if err := bus.Transmit([]byte{0x04, 0x8F}); err != nil {
+		t.Fatalf("give device power status: %v", err)
+	}
+	if err := bus.Transmit([]byte{0x0F, 0x86, 0x20, 0x00}); err != nil {
+		t.Fatalf("set stream path: %v", err)
+	}
+
+	frames := bus.Frames()
+	replies := 0
+	for _, f := range frames {
+		if f[0]>>4 == 4 {
+			replies++
+		}
+	}
+	if replies != 2 || !containsFrame(frames, []byte{0x40, 0x90, 0x00}) || !containsFrame(frames, []byte{0x4F, 0x82, 0x20, 0x00}) {
+		t.Errorf("replies in % x, want one power status and one active source", frames)
+	}
+	if got := player.GetActiveSource(); got != 4 {
+		t.Errorf("active source = %d, want 4", got)
+	}
+}
+
+func containsFrame(frames [][]byte, frame []byte) bool {
+	for _, f := range frames {
+		if bytes.Equal(f, frame) {
+			return true
+		}
+	}
+	return false
+}
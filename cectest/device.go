@@ -0,0 +1,436 @@
+package cectest
+
+// device types as reported in Report Physical Address
+const (
+	DeviceTypeTV        = 0x00
+	DeviceTypeRecording = 0x01
+	DeviceTypeReserved  = 0x02
+	DeviceTypeTuner     = 0x03
+	DeviceTypePlayback  = 0x04
+	DeviceTypeAudio     = 0x05
+)
+
+// power status codes
+const (
+	PowerOn          = 0x00
+	PowerStandby     = 0x01
+	PowerStandbyToOn = 0x02
+	PowerOnToStandby = 0x03
+)
+
+// Device - a simulated device on the bus. The exported fields can be set
+// before the device is added to a Bus, afterwards use the Bus methods so
+// the state is changed under the bus lock.
+type Device struct {
+	LogicalAddress  int
+	PhysicalAddress uint16
+	DeviceType      int
+	OSDName         string
+	VendorID        uint64
+	CECVersion      byte
+	MenuLanguage    string
+	PowerStatus     int
+	Volume          int
+	Muted           bool
+	SystemAudioMode bool
+	ARC             bool
+	// DeckInfo - the Deck Status of a playback or recording device, 0 for
+	// devices without a deck
+	DeckInfo byte
+	// TunerInfo - the Tuner Device Info (display info and the selected
+	// service) of a tuner, nil for devices without a tuner
+	TunerInfo []byte
+	// Recording - whether a recording device records
+	Recording bool
+	// Timers - the operands of the timers set on a recording device
+	Timers [][]byte
+	// OSDString - the text a TV shows (Set OSD String), empty if none
+	OSDString string
+
+	// Handler is called for every frame addressed to the device (or
+	// broadcast) before the default handling. It returns the reply frames
+	// to send and whether the frame was handled.
+	Handler func(d *Device, frame []byte) (replies [][]byte, handled bool)
+
+	bus *Bus
+}
+
+// NewTV - a TV at 0.0.0.0, in standby
+func NewTV() *Device {
+	return &Device{
+		LogicalAddress:  0,
+		PhysicalAddress: 0x0000,
+		DeviceType:      DeviceTypeTV,
+		OSDName:         "TV",
+		VendorID:        0x0000F0,
+		CECVersion:      0x05,
+		MenuLanguage:    "eng",
+		PowerStatus:     PowerStandby,
+	}
+}
+
+// NewAudioSystem - an AVR at the given physical address
+func NewAudioSystem(physicalAddress uint16) *Device {
+	return &Device{
+		LogicalAddress:  5,
+		PhysicalAddress: physicalAddress,
+		DeviceType:      DeviceTypeAudio,
+		OSDName:         "AVR",
+		VendorID:        0x00A0DE,
+		CECVersion:      0x05,
+		PowerStatus:     PowerStandby,
+		Volume:          20,
+	}
+}
+
+// NewPlayback - a playback device with the given logical address (4, 8
+// or 11), physical address and OSD name
+func NewPlayback(logicalAddress int, physicalAddress uint16, name string) *Device {
+	return &Device{
+		LogicalAddress:  logicalAddress,
+		PhysicalAddress: physicalAddress,
+		DeviceType:      DeviceTypePlayback,
+		OSDName:         name,
+		VendorID:        0x080046,
+		CECVersion:      0x05,
+		PowerStatus:     PowerStandby,
+		DeckInfo:        0x1A, // stop
+	}
+}
+
+// NewTuner - a set-top box with the given logical address (3, 6, 7 or
+// 10), physical address and OSD name, showing DVB-T service 1/1/1
+func NewTuner(logicalAddress int, physicalAddress uint16, name string) *Device {
+	return &Device{
+		LogicalAddress:  logicalAddress,
+		PhysicalAddress: physicalAddress,
+		DeviceType:      DeviceTypeTuner,
+		OSDName:         name,
+		VendorID:        0x00903E,
+		CECVersion:      0x05,
+		PowerStatus:     PowerStandby,
+		TunerInfo:       []byte{0x00, 0x1B, 0x00, 0x01, 0x00, 0x01, 0x00, 0x01},
+	}
+}
+
+// NewRecorder - a recording device with the given logical address (1, 2
+// or 9), physical address and OSD name
+func NewRecorder(logicalAddress int, physicalAddress uint16, name string) *Device {
+	return &Device{
+		LogicalAddress:  logicalAddress,
+		PhysicalAddress: physicalAddress,
+		DeviceType:      DeviceTypeRecording,
+		OSDName:         name,
+		VendorID:        0x008045,
+		CECVersion:      0x05,
+		PowerStatus:     PowerStandby,
+		DeckInfo:        0x1A, // stop
+	}
+}
+
+func (d *Device) header(destination int) byte {
+	return byte(d.LogicalAddress<<4 | destination&0xF)
+}
+
+func (d *Device) audioStatus() byte {
+	status := byte(d.Volume & 0x7F)
+	if d.Muted {
+		status |= 0x80
+	}
+	return status
+}
+
+// handle - default behaviour of a device for a received frame, returns
+// the frames the device sends in reply
+func (d *Device) handle(frame []byte) [][]byte {
+	if d.Handler != nil {
+		if replies, handled := d.Handler(d, frame); handled {
+			return replies
+		}
+	}
+
+	if len(frame) < 2 {
+		return nil
+	}
+
+	initiator := int(frame[0] >> 4)
+	broadcast := frame[0]&0xF == 0xF
+	opcode := frame[1]
+	params := frame[2:]
+
+	switch opcode {
+	case 0x46: // give OSD name
+		return [][]byte{append([]byte{d.header(initiator), 0x47}, d.OSDName...)}
+	case 0x83: // give physical address
+		return [][]byte{d.reportPhysicalAddress()}
+	case 0x8F: // give device power status
+		return [][]byte{{d.header(initiator), 0x90, byte(d.PowerStatus)}}
+	case 0x8C: // give device vendor id
+		return [][]byte{{d.header(0xF), 0x87, byte(d.VendorID >> 16), byte(d.VendorID >> 8), byte(d.VendorID)}}
+	case 0x9F: // get CEC version
+		return [][]byte{{d.header(initiator), 0x9E, d.CECVersion}}
+	case 0x91: // get menu language
+		if d.MenuLanguage == "" {
+			break
+		}
+		return [][]byte{append([]byte{d.header(0xF), 0x32}, d.MenuLanguage...)}
+	case 0x04, 0x0D: // image view on, text view on
+		if d.DeviceType == DeviceTypeTV {
+			d.PowerStatus = PowerOn
+			return nil
+		}
+	case 0x36: // standby
+		d.PowerStatus = PowerStandby
+		if d.bus.activeSource == d.LogicalAddress {
+			d.bus.activeSource = -1
+		}
+		return nil
+	case 0x85: // request active source
+		if d.bus.activeSource == d.LogicalAddress {
+			return [][]byte{d.activeSource()}
+		}
+		return nil
+	case 0x86: // set stream path
+		if len(params) >= 2 && uint16(params[0])<<8|uint16(params[1]) == d.PhysicalAddress && d.DeviceType != DeviceTypeTV {
+			d.PowerStatus = PowerOn
+			return [][]byte{d.activeSource()}
+		}
+		return nil
+	case 0x44: // user control pressed
+		if len(params) < 1 {
+			break
+		}
+		return d.userControl(initiator, params[0])
+	case 0x45: // user control released
+		return nil
+	case 0x71: // give audio status
+		if d.DeviceType == DeviceTypeAudio {
+			return [][]byte{{d.header(initiator), 0x7A, d.audioStatus()}}
+		}
+	case 0x70: // system audio mode request
+		if d.DeviceType == DeviceTypeAudio {
+			d.SystemAudioMode = len(params) >= 2
+			return [][]byte{{d.header(0xF), 0x72, boolByte(d.SystemAudioMode)}}
+		}
+	case 0x7D: // give system audio mode status
+		if d.DeviceType == DeviceTypeAudio {
+			return [][]byte{{d.header(initiator), 0x7E, boolByte(d.SystemAudioMode)}}
+		}
+	case 0x72: // set system audio mode
+		if len(params) >= 1 {
+			d.SystemAudioMode = params[0] == 1
+		}
+		return nil
+	case 0xC3, 0xC4: // request ARC initiation, request ARC termination
+		if d.DeviceType == DeviceTypeAudio {
+			if opcode == 0xC3 {
+				return [][]byte{{d.header(initiator), 0xC0}}
+			}
+			return [][]byte{{d.header(initiator), 0xC5}}
+		}
+	case 0xC0, 0xC5: // initiate ARC, terminate ARC
+		if d.DeviceType == DeviceTypeTV {
+			d.ARC = opcode == 0xC0
+			if d.ARC {
+				return [][]byte{{d.header(initiator), 0xC1}}
+			}
+			return [][]byte{{d.header(initiator), 0xC2}}
+		}
+	case 0xC1, 0xC2: // report ARC initiated, report ARC terminated
+		d.ARC = opcode == 0xC1
+		return nil
+	case 0x1A: // give deck status
+		if d.DeckInfo == 0 {
+			break
+		}
+		if len(params) >= 1 && params[0] == 0x02 { // off
+			return nil
+		}
+		return [][]byte{{d.header(initiator), 0x1B, d.DeckInfo}}
+	case 0x41, 0x42: // play, deck control
+		if d.DeckInfo != 0 && len(params) >= 1 {
+			d.deck(opcode, params[0])
+			return nil
+		}
+	case 0x08: // give tuner device status
+		if d.TunerInfo == nil {
+			break
+		}
+		if len(params) >= 1 && params[0] == 0x02 { // off
+			return nil
+		}
+		return [][]byte{append([]byte{d.header(initiator), 0x07}, d.TunerInfo...)}
+	case 0x93, 0x92: // select digital service, select analogue service
+		if d.TunerInfo == nil {
+			break
+		}
+		display := byte(0x00)
+		if opcode == 0x92 {
+			display = 0x02
+		}
+		d.TunerInfo = append([]byte{display}, params...)
+		return nil
+	case 0x05, 0x06: // tuner step increment, tuner step decrement
+		if d.TunerInfo != nil {
+			return nil
+		}
+	case 0x09: // record on
+		if d.DeviceType != DeviceTypeRecording || len(params) < 1 {
+			break
+		}
+		if d.Recording {
+			return [][]byte{{d.header(initiator), 0x0A, 0x12}} // already recording
+		}
+		d.Recording = true
+		d.DeckInfo = 0x12
+		// recording own source, digital service, analogue service or
+		// external input
+		status := params[0]
+		if status > 0x04 {
+			status = 0x04
+		}
+		return [][]byte{{d.header(initiator), 0x0A, status}}
+	case 0x0B: // record off
+		if d.DeviceType != DeviceTypeRecording {
+			break
+		}
+		if !d.Recording {
+			return [][]byte{{d.header(initiator), 0x0A, 0x1B}}
+		}
+		d.Recording = false
+		d.DeckInfo = 0x1A
+		return [][]byte{{d.header(initiator), 0x0A, 0x1A}}
+	case 0x34, 0x97, 0xA2: // set analogue, digital, external timer
+		if d.DeviceType != DeviceTypeRecording {
+			break
+		}
+		for _, timer := range d.Timers {
+			if string(timer) == string(params) {
+				// not programmed: duplicate, 99:59 available
+				return [][]byte{{d.header(initiator), 0x35, 0x0E, 0x99, 0x59}}
+			}
+		}
+		d.Timers = append(d.Timers, append([]byte(nil), params...))
+		// programmed, enough space
+		return [][]byte{{d.header(initiator), 0x35, 0x18}}
+	case 0x33, 0x99, 0xA1: // clear analogue, digital, external timer
+		if d.DeviceType != DeviceTypeRecording {
+			break
+		}
+		for i, timer := range d.Timers {
+			if string(timer) == string(params) {
+				d.Timers = append(d.Timers[:i], d.Timers[i+1:]...)
+				return [][]byte{{d.header(initiator), 0x43, 0x80}}
+			}
+		}
+		return [][]byte{{d.header(initiator), 0x43, 0x01}} // no matching timer
+	case 0x64: // set OSD string
+		if d.DeviceType != DeviceTypeTV || len(params) < 1 {
+			break
+		}
+		if params[0] == 0x80 { // clear previous message
+			d.OSDString = ""
+		} else {
+			d.OSDString = string(params[1:])
+		}
+		return nil
+	case 0x67: // set timer program title
+		if d.DeviceType == DeviceTypeRecording {
+			return nil
+		}
+	case 0x00, 0x82, 0x84, 0x87, 0x80, 0x81, 0x9D, 0x47, 0x90, 0x9E, 0x32, 0x7A, 0x7E, 0x1B, 0x07, 0x0A, 0x35, 0x43:
+		// informational messages, nothing to reply
+		return nil
+	}
+
+	if broadcast {
+		return nil
+	}
+
+	// feature abort: unrecognized opcode (or refused for <abort>)
+	reason := byte(0x00)
+	if opcode == 0xFF {
+		reason = 0x04
+	}
+	return [][]byte{{d.header(initiator), 0x00, opcode, reason}}
+}
+
+func (d *Device) userControl(initiator int, key byte) [][]byte {
+	switch key {
+	case 0x40, 0x6B: // power, power toggle
+		if d.PowerStatus == PowerOn {
+			d.PowerStatus = PowerStandby
+		} else {
+			d.PowerStatus = PowerOn
+		}
+	case 0x6D: // power on
+		d.PowerStatus = PowerOn
+	case 0x6C: // power off
+		d.PowerStatus = PowerStandby
+	case 0x41, 0x42, 0x43: // volume up, volume down, mute
+		if d.DeviceType != DeviceTypeAudio {
+			return nil
+		}
+		switch key {
+		case 0x41:
+			if d.Volume < 100 {
+				d.Volume++
+			}
+		case 0x42:
+			if d.Volume > 0 {
+				d.Volume--
+			}
+		case 0x43:
+			d.Muted = !d.Muted
+		}
+		return [][]byte{{d.header(initiator), 0x7A, d.audioStatus()}}
+	}
+	return nil
+}
+
+// deck - the deck info after Play or Deck Control
+func (d *Device) deck(opcode byte, mode byte) {
+	if opcode == 0x42 {
+		switch mode {
+		case 0x01, 0x02: // skip forward, skip reverse
+			d.DeckInfo = 0x1A + mode
+		case 0x03: // stop
+			d.DeckInfo = 0x1A
+		case 0x04: // eject
+			d.DeckInfo = 0x19
+		}
+		return
+	}
+
+	switch {
+	case mode == 0x24:
+		d.DeckInfo = 0x11
+	case mode == 0x20:
+		d.DeckInfo = 0x13
+	case mode == 0x25:
+		d.DeckInfo = 0x14
+	case mode >= 0x05 && mode <= 0x07:
+		d.DeckInfo = 0x17
+	case mode >= 0x09 && mode <= 0x0B:
+		d.DeckInfo = 0x18
+	case mode >= 0x15 && mode <= 0x17:
+		d.DeckInfo = 0x15
+	case mode >= 0x19 && mode <= 0x1B:
+		d.DeckInfo = 0x16
+	}
+}
+
+func (d *Device) reportPhysicalAddress() []byte {
+	return []byte{d.header(0xF), 0x84, byte(d.PhysicalAddress >> 8), byte(d.PhysicalAddress), byte(d.DeviceType)}
+}
+
+func (d *Device) activeSource() []byte {
+	return []byte{d.header(0xF), 0x82, byte(d.PhysicalAddress >> 8), byte(d.PhysicalAddress)}
+}
+
+func boolByte(b bool) byte {
+	if b {
+		return 1
+	}
+	return 0
+}
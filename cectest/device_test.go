@@ -0,0 +1,107 @@
+package cectest
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDeviceHandle(t *testing.T) {
+	tests := []struct {
+		name   string
+		device func() *Device
+		frame  []byte
+		want   [][]byte
+		check  func(d *Device) bool
+	}{
+		{name: "give OSD name", device: NewTV, frame: []byte{0x40, 0x46},
+			want: [][]byte{append([]byte{0x04, 0x47}, "TV"...)}},
+		{name: "give physical address", device: func() *Device { return NewAudioSystem(0x1000) },
+			frame: []byte{0x45, 0x83}, want: [][]byte{{0x5F, 0x84, 0x10, 0x00, DeviceTypeAudio}}},
+		{name: "give power status", device: NewTV, frame: []byte{0x40, 0x8F},
+			want: [][]byte{{0x04, 0x90, PowerStandby}}},
+		{name: "give vendor id", device: NewTV, frame: []byte{0x40, 0x8C},
+			want: [][]byte{{0x0F, 0x87, 0x00, 0x00, 0xF0}}},
+		{name: "get menu language", device: NewTV, frame: []byte{0x40, 0x91},
+			want: [][]byte{append([]byte{0x0F, 0x32}, "eng"...)}},
+		{name: "image view on", device: NewTV, frame: []byte{0x40, 0x04},
+			check: func(d *Device) bool { return d.PowerStatus == PowerOn }},
+		{name: "standby", device: func() *Device { d := NewTV(); d.PowerStatus = PowerOn; return d },
+			frame: []byte{0x4F, 0x36}, check: func(d *Device) bool { return d.PowerStatus == PowerStandby }},
+		{name: "set stream path", device: func() *Device { return NewPlayback(4, 0x1000, "Player") },
+			frame: []byte{0x0F, 0x86, 0x10, 0x00}, want: [][]byte{{0x4F, 0x82, 0x10, 0x00}},
+			check: func(d *Device) bool { return d.PowerStatus == PowerOn }},
+		{name: "set stream path elsewhere", device: func() *Device { return NewPlayback(4, 0x1000, "Player") },
+			frame: []byte{0x0F, 0x86, 0x20, 0x00}},
+		{name: "volume up", device: func() *Device { return NewAudioSystem(0x1000) },
+			frame: []byte{0x45, 0x44, 0x41}, want: [][]byte{{0x54, 0x7A, 21}},
+			check: func(d *Device) bool { return d.Volume == 21 }},
+		{name: "mute", device: func() *Device { return NewAudioSystem(0x1000) },
+			frame: []byte{0x45, 0x44, 0x43}, want: [][]byte{{0x54, 0x7A, 0x80 | 20}}},
+		{name: "deck control eject", device: func() *Device { return NewPlayback(4, 0x1000, "Player") },
+			frame: []byte{0x04, 0x42, 0x04}, check: func(d *Device) bool { return d.DeckInfo == 0x19 }},
+		{name: "unknown opcode", device: NewTV, frame: []byte{0x40, 0xF0},
+			want: [][]byte{{0x04, 0x00, 0xF0, 0x00}}},
+		{name: "abort", device: NewTV, frame: []byte{0x40, 0xFF},
+			want: [][]byte{{0x04, 0x00, 0xFF, 0x04}}},
+		{name: "unknown broadcast", device: NewTV, frame: []byte{0x4F, 0xF0}},
+		{name: "informational", device: NewTV, frame: []byte{0x40, 0x90, 0x00}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := tt.device()
+			NewBus(d)
+
+			got := d.handle(tt.frame)
+			if len(got) != len(tt.want) {
+				t.Fatalf("replies % x, want % x", got, tt.want)
+			}
+			for i := range tt.want {
+				if !bytes.Equal(got[i], tt.want[i]) {
+					t.Errorf("reply %d = % x, want % x", i, got[i], tt.want[i])
+				}
+			}
+			if tt.check != nil && !tt.check(d) {
+				t.Errorf("unexpected state after % x: %+v", tt.frame, d)
+			}
+		})
+	}
+}
+
+func TestDeviceHandler(t *testing.T) {
+	d := NewTV()
+	d.Handler = func(d *Device, frame []byte) ([][]byte, bool) {
+		if len(frame) > 1 && frame[1] == 0x46 {
+			return [][]byte{append([]byte{0x04, 0x47}, "Custom"...)}, true
+		}
+		return nil, false
+	}
+	NewBus(d)
+
+	if got := d.handle([]byte{0x40, 0x46}); len(got) != 1 || string(got[0][2:]) != "Custom" {
+		t.Errorf("handled frame replies % x, want the custom name", got)
+	}
+	// not handled frames get the default handling
+	if got := d.handle([]byte{0x40, 0x8F}); len(got) != 1 || got[0][1] != 0x90 {
+		t.Errorf("default frame replies % x, want a power status", got)
+	}
+}
+
+func TestRecorderTimers(t *testing.T) {
+	d := NewRecorder(1, 0x1000, "Recorder")
+	NewBus(d)
+
+	timer := []byte{0x03, 0x04, 0x12, 0x00, 0x01, 0x00, 0x00, 0x01}
+	if got := d.handle(append([]byte{0x41, 0x34}, timer...)); len(got) != 1 || got[0][2] != 0x18 {
+		t.Fatalf("set timer replies % x, want programmed", got)
+	}
+	if got := d.handle(append([]byte{0x41, 0x34}, timer...)); len(got) != 1 || got[0][2] != 0x0E {
+		t.Errorf("duplicate timer replies % x, want not programmed", got)
+	}
+	if got := d.handle(append([]byte{0x41, 0x33}, timer...)); len(got) != 1 || got[0][2] != 0x80 {
+		t.Errorf("clear timer replies % x, want cleared", got)
+	}
+	if len(d.Timers) != 0 {
+		t.Errorf("timers after clear: % x", d.Timers)
+	}
+}
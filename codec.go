@@ -0,0 +1,138 @@
+package cec
+
+import (
+	"fmt"
+)
+
+// Message - a typed CEC message. Every opcode has its own struct (SetOSDName,
+// ReportPowerStatus, ...), opcodes without one decode to RawMessage.
+type Message interface {
+	Opcode() Opcode
+	// MarshalOperands - the operands as sent on the bus (without header and
+	// opcode)
+	MarshalOperands() ([]byte, error)
+}
+
+// RawMessage - a message with an opcode the codec does not know, operands
+// are kept as received
+type RawMessage struct {
+	Code     Opcode
+	Operands []byte
+}
+
+func (m RawMessage) Opcode() Opcode { return m.Code }
+
+func (m RawMessage) MarshalOperands() ([]byte, error) {
+	if len(m.Operands) > 14 {
+		return nil, fmt.Errorf("%w: too many operands", ErrInvalidFrame)
+	}
+	return m.Operands, nil
+}
+
+// Encode - the raw frame for msg sent from initiator to destination
+func Encode(initiator, destination LogicalAddress, msg Message) ([]byte, error) {
+	if !initiator.IsValid() || !destination.IsValid() {
+		return nil, fmt.Errorf("%w: invalid logical address", ErrInvalidFrame)
+	}
+	if msg == nil {
+		return nil, fmt.Errorf("%w: no message given", ErrInvalidFrame)
+	}
+
+	operands, err := msg.MarshalOperands()
+	if err != nil {
+		return nil, err
+	}
+	if len(operands) > 14 {
+		return nil, fmt.Errorf("%w: %s: too many operands", ErrInvalidFrame, msg.Opcode())
+	}
+
+	frame := []byte{byte(initiator<<4 | destination), byte(msg.Opcode())}
+	return append(frame, operands...), nil
+}
+
+// Decode - decode the operands of a message with the given opcode. Unknown
+// opcodes decode to RawMessage, operands beyond the ones an opcode defines
+// are ignored (as CEC requires for forward compatibility).
+func Decode(opcode Opcode, operands []byte) (Message, error) {
+	operands = append([]byte(nil), operands...)
+
+	decode, ok := decoders[opcode]
+	if !ok {
+		return RawMessage{Code: opcode, Operands: operands}, nil
+	}
+	return decode(operands)
+}
+
+// DecodeFrame - decode a raw frame into the initiator, destination and
+// message. A polling message (header only) has no message.
+func DecodeFrame(frame []byte) (initiator, destination LogicalAddress, msg Message, err error) {
+	if len(frame) == 0 {
+		return 0, 0, nil, fmt.Errorf("%w: empty frame", ErrInvalidFrame)
+	}
+	initiator = LogicalAddress(frame[0] >> 4)
+	destination = LogicalAddress(frame[0] & 0xF)
+	if len(frame) == 1 {
+		return initiator, destination, nil, nil
+	}
+	msg, err = Decode(Opcode(frame[1]), frame[2:])
+	return initiator, destination, msg, err
+}
+
+// needOperands - check that at least n operand bytes are there
+func needOperands(opcode Opcode, operands []byte, n int) error {
+	if len(operands) < n {
+		return fmt.Errorf("%w: %s: expected %d operand bytes, got %d", ErrInvalidFrame, opcode, n, len(operands))
+	}
+	return nil
+}
+
+// asciiOperand - check a string operand and return its bytes
+func asciiOperand(opcode Opcode, s string, min, max int) ([]byte, error) {
+	if len(s) < min || len(s) > max {
+		return nil, fmt.Errorf("%w: %s: expected %d to %d characters, got %d", ErrInvalidFrame, opcode, min, max, len(s))
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < 0x20 || s[i] > 0x7E {
+			return nil, fmt.Errorf("%w: %s: invalid character %q", ErrInvalidFrame, opcode, s[i])
+		}
+	}
+	return []byte(s), nil
+}
+
+// bytesOperand - check the length of a variable length operand
+func bytesOperand(opcode Opcode, b []byte, min, max int) ([]byte, error) {
+	if len(b) < min || len(b) > max {
+		return nil, fmt.Errorf("%w: %s: expected %d to %d operand bytes, got %d", ErrInvalidFrame, opcode, min, max, len(b))
+	}
+	return b, nil
+}
+
+func physicalAddressOperand(b []byte) PhysicalAddress {
+	return PhysicalAddress(b[0])<<8 | PhysicalAddress(b[1])
+}
+
+func appendPhysicalAddress(b []byte, address PhysicalAddress) []byte {
+	return append(b, byte(address>>8), byte(address))
+}
+
+func vendorIDOperand(b []byte) uint32 {
+	return uint32(b[0])<<16 | uint32(b[1])<<8 | uint32(b[2])
+}
+
+func appendVendorID(b []byte, id uint32) ([]byte, error) {
+	if id > 0xFFFFFF {
+		return nil, fmt.Errorf("%w: vendor ID out of range", ErrInvalidFrame)
+	}
+	return append(b, byte(id>>16), byte(id>>8), byte(id)), nil
+}
+
+func boolOperand(b byte) bool {
+	return b == 1
+}
+
+func appendBool(b []byte, v bool) []byte {
+	if v {
+		return append(b, 1)
+	}
+	return append(b, 0)
+}
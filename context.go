@@ -0,0 +1,201 @@
+package cec
+
+import (
+	"context"
+	"time"
+)
+
+// do - run a backend call, returning early with the context's error when
+// it is done first. Backend calls (libcec in particular) cannot be
+// interrupted, an abandoned call finishes in the background. fn must hand
+// its result over through a buffered channel, not by writing a variable
+// of the caller, which may have returned already. Destroy waits for
+// abandoned calls before closing the backend.
+func (c *Connection) do(ctx context.Context, fn func()) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	tracked := !c.closed
+	if tracked {
+		c.calls.Add(1)
+	}
+	c.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		if tracked {
+			defer c.calls.Done()
+		}
+		fn()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// doErr - like do for backend calls returning an error
+func (c *Connection) doErr(ctx context.Context, fn func() error) error {
+	ch := make(chan error, 1)
+	if err := c.do(ctx, func() { ch <- fn() }); err != nil {
+		return err
+	}
+	return <-ch
+}
+
+// TransmitContext - Transmit with a context
+func (c *Connection) TransmitContext(ctx context.Context, command string) error {
+	return c.doErr(ctx, func() error { return c.Transmit(command) })
+}
+
+// SendContext - Send with a context
+func (c *Connection) SendContext(ctx context.Context, destination LogicalAddress, msg Message) error {
+	return c.doErr(ctx, func() error { return c.Send(destination, msg) })
+}
+
+// PowerOnContext - PowerOn with a context
+func (c *Connection) PowerOnContext(ctx context.Context, address LogicalAddress) error {
+	return c.doErr(ctx, func() error { return c.PowerOn(address) })
+}
+
+// StandbyContext - Standby with a context
+func (c *Connection) StandbyContext(ctx context.Context, address LogicalAddress) error {
+	return c.doErr(ctx, func() error { return c.Standby(address) })
+}
+
+// VolumeUpContext - VolumeUp with a context
+func (c *Connection) VolumeUpContext(ctx context.Context) error {
+	return c.doErr(ctx, c.VolumeUp)
+}
+
+// VolumeDownContext - VolumeDown with a context
+func (c *Connection) VolumeDownContext(ctx context.Context) error {
+	return c.doErr(ctx, c.VolumeDown)
+}
+
+// MuteContext - Mute with a context
+func (c *Connection) MuteContext(ctx context.Context) error {
+	return c.doErr(ctx, c.Mute)
+}
+
+// KeyPressContext - KeyPress with a context
+func (c *Connection) KeyPressContext(ctx context.Context, address LogicalAddress, key UserControlCode) error {
+	return c.doErr(ctx, func() error { return c.KeyPress(address, key) })
+}
+
+// KeyReleaseContext - KeyRelease with a context
+func (c *Connection) KeyReleaseContext(ctx context.Context, address LogicalAddress) error {
+	return c.doErr(ctx, func() error { return c.KeyRelease(address) })
+}
+
+// KeyContext - Key with a context. Once the key is pressed the release is
+// always sent, even if the context is done in between.
+func (c *Connection) KeyContext(ctx context.Context, address LogicalAddress, key interface{}) error {
+	keycode, err := parseKey(key)
+	if err != nil {
+		return err
+	}
+
+	if err := c.KeyPressContext(ctx, address, keycode); err != nil {
+		return err
+	}
+
+	select {
+	case <-time.After(10 * time.Millisecond):
+	case <-ctx.Done():
+	}
+	return c.KeyRelease(address)
+}
+
+// GetActiveDevicesContext - GetActiveDevices with a context
+func (c *Connection) GetActiveDevicesContext(ctx context.Context) ([16]bool, error) {
+	ch := make(chan [16]bool, 1)
+	if err := c.do(ctx, func() { ch <- c.GetActiveDevices() }); err != nil {
+		return [16]bool{}, err
+	}
+	return <-ch, nil
+}
+
+// GetActiveSourceContext - GetActiveSource with a context
+func (c *Connection) GetActiveSourceContext(ctx context.Context) (LogicalAddress, error) {
+	ch := make(chan LogicalAddress, 1)
+	if err := c.do(ctx, func() { ch <- c.GetActiveSource() }); err != nil {
+		return 0, err
+	}
+	return <-ch, nil
+}
+
+// IsActiveSourceContext - IsActiveSource with a context
+func (c *Connection) IsActiveSourceContext(ctx context.Context, address LogicalAddress) (bool, error) {
+	ch := make(chan bool, 1)
+	if err := c.do(ctx, func() { ch <- c.IsActiveSource(address) }); err != nil {
+		return false, err
+	}
+	return <-ch, nil
+}
+
+// PollDeviceContext - PollDevice with a context
+func (c *Connection) PollDeviceContext(ctx context.Context, address LogicalAddress) (bool, error) {
+	ch := make(chan bool, 1)
+	if err := c.do(ctx, func() { ch <- c.PollDevice(address) }); err != nil {
+		return false, err
+	}
+	return <-ch, nil
+}
+
+// GetDeviceOSDNameContext - GetDeviceOSDName with a context
+func (c *Connection) GetDeviceOSDNameContext(ctx context.Context, address LogicalAddress) (string, error) {
+	ch := make(chan string, 1)
+	if err := c.do(ctx, func() { ch <- c.GetDeviceOSDName(address) }); err != nil {
+		return "", err
+	}
+	return <-ch, nil
+}
+
+// GetDeviceVendorIDContext - GetDeviceVendorID with a context
+func (c *Connection) GetDeviceVendorIDContext(ctx context.Context, address LogicalAddress) (uint64, error) {
+	ch := make(chan uint64, 1)
+	if err := c.do(ctx, func() { ch <- c.GetDeviceVendorID(address) }); err != nil {
+		return 0, err
+	}
+	return <-ch, nil
+}
+
+// GetDevicePhysicalAddressContext - GetDevicePhysicalAddress with a context
+func (c *Connection) GetDevicePhysicalAddressContext(ctx context.Context, address LogicalAddress) (PhysicalAddress, error) {
+	ch := make(chan PhysicalAddress, 1)
+	if err := c.do(ctx, func() { ch <- c.GetDevicePhysicalAddress(address) }); err != nil {
+		return 0, err
+	}
+	return <-ch, nil
+}
+
+// GetDevicePowerStatusContext - GetDevicePowerStatus with a context
+func (c *Connection) GetDevicePowerStatusContext(ctx context.Context, address LogicalAddress) (PowerStatus, error) {
+	ch := make(chan PowerStatus, 1)
+	if err := c.do(ctx, func() { ch <- c.GetDevicePowerStatus(address) }); err != nil {
+		return 0, err
+	}
+	return <-ch, nil
+}
+
+// GetAudioStatusContext - GetAudioStatus with a context
+func (c *Connection) GetAudioStatusContext(ctx context.Context) (AudioStatus, error) {
+	ch := make(chan AudioStatus, 1)
+	if err := c.do(ctx, func() { ch <- c.GetAudioStatus() }); err != nil {
+		return AudioStatus{}, err
+	}
+	return <-ch, nil
+}
+
+// ListContext - List with a context, the devices found until the context
+// is done are returned with its error
+func (c *Connection) ListContext(ctx context.Context) (map[string]Device, error) {
+	return c.registry.List(ctx)
+}
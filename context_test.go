@@ -0,0 +1,77 @@
+package cec_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/chbmuc/cec"
+	"github.com/chbmuc/cec/cectest"
+)
+
+// slow - a backend whose queries take longer than the tests wait
+type slow struct {
+	cec.Backend
+}
+
+const slowDelay = 20 * time.Millisecond
+
+func (s slow) GetActiveDevices() [16]bool {
+	time.Sleep(slowDelay)
+	return s.Backend.GetActiveDevices()
+}
+
+func (s slow) GetDeviceOSDName(address int) string {
+	time.Sleep(slowDelay)
+	return s.Backend.GetDeviceOSDName(address)
+}
+
+func (s slow) Standby(address int) error {
+	time.Sleep(slowDelay)
+	return s.Backend.Standby(address)
+}
+
+func TestContextCancel(t *testing.T) {
+	bus := cectest.NewBus(cectest.NewTV())
+	c, err := cec.OpenBackend(slow{bus.NewBackend(cectest.NewPlayback(4, 0x1000, "cec.go"))})
+	if err != nil {
+		t.Fatalf("OpenBackend: %v", err)
+	}
+	defer c.Destroy()
+
+	expired := func() context.Context {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+		t.Cleanup(cancel)
+		return ctx
+	}
+
+	devices, err := c.GetActiveDevicesContext(expired())
+	if !errors.Is(err, context.DeadlineExceeded) || devices != [16]bool{} {
+		t.Errorf("GetActiveDevicesContext = %v, %v, want the deadline", devices, err)
+	}
+	name, err := c.GetDeviceOSDNameContext(expired(), cec.TV)
+	if !errors.Is(err, context.DeadlineExceeded) || name != "" {
+		t.Errorf("GetDeviceOSDNameContext = %q, %v, want the deadline", name, err)
+	}
+	if err := c.StandbyContext(expired(), cec.TV); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("StandbyContext = %v, want the deadline", err)
+	}
+
+	// let the abandoned calls finish, the race detector reports it if they
+	// write to what was returned
+	time.Sleep(3 * slowDelay)
+}
+
+func TestContextResult(t *testing.T) {
+	c, _ := openBus(t, cectest.NewTV())
+
+	devices, err := c.GetActiveDevicesContext(context.Background())
+	if err != nil || !devices[0] || !devices[4] || devices[5] {
+		t.Errorf("GetActiveDevicesContext = %v, %v, want the TV and the own device", devices, err)
+	}
+	name, err := c.GetDeviceOSDNameContext(context.Background(), cec.TV)
+	if err != nil || name != "TV" {
+		t.Errorf("GetDeviceOSDNameContext = %q, %v, want TV", name, err)
+	}
+}
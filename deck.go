@@ -0,0 +1,189 @@
+package cec
+
+import "context"
+
+// DeckControlMode - the operand of Deck Control
+type DeckControlMode byte
+
+// deck control modes as used on the bus
+const (
+	DeckControlSkipForward DeckControlMode = 0x01
+	DeckControlSkipReverse DeckControlMode = 0x02
+	DeckControlStop        DeckControlMode = 0x03
+	DeckControlEject       DeckControlMode = 0x04
+)
+
+var deckControlModeNames = map[DeckControlMode]string{
+	DeckControlSkipForward: "skip forward", DeckControlSkipReverse: "skip reverse",
+	DeckControlStop: "stop", DeckControlEject: "eject"}
+
+// IsValid - whether the mode is defined by the standard
+func (m DeckControlMode) IsValid() bool {
+	_, ok := deckControlModeNames[m]
+	return ok
+}
+
+func (m DeckControlMode) String() string {
+	if name, ok := deckControlModeNames[m]; ok {
+		return name
+	}
+	return "Unknown"
+}
+
+// PlayMode - the operand of Play
+type PlayMode byte
+
+// play modes as used on the bus, fast and slow in three speeds (min,
+// medium, max)
+const (
+	PlayForward        PlayMode = 0x24
+	PlayReverse        PlayMode = 0x20
+	PlayStill          PlayMode = 0x25
+	PlayFastForwardMin PlayMode = 0x05
+	PlayFastForwardMed PlayMode = 0x06
+	PlayFastForwardMax PlayMode = 0x07
+	PlayFastReverseMin PlayMode = 0x09
+	PlayFastReverseMed PlayMode = 0x0A
+	PlayFastReverseMax PlayMode = 0x0B
+	PlaySlowForwardMin PlayMode = 0x15
+	PlaySlowForwardMed PlayMode = 0x16
+	PlaySlowForwardMax PlayMode = 0x17
+	PlaySlowReverseMin PlayMode = 0x19
+	PlaySlowReverseMed PlayMode = 0x1A
+	PlaySlowReverseMax PlayMode = 0x1B
+)
+
+var playModeNames = map[PlayMode]string{PlayForward: "forward",
+	PlayReverse: "reverse", PlayStill: "still",
+	PlayFastForwardMin: "fast forward 1", PlayFastForwardMed: "fast forward 2",
+	PlayFastForwardMax: "fast forward 3", PlayFastReverseMin: "fast reverse 1",
+	PlayFastReverseMed: "fast reverse 2", PlayFastReverseMax: "fast reverse 3",
+	PlaySlowForwardMin: "slow forward 1", PlaySlowForwardMed: "slow forward 2",
+	PlaySlowForwardMax: "slow forward 3", PlaySlowReverseMin: "slow reverse 1",
+	PlaySlowReverseMed: "slow reverse 2", PlaySlowReverseMax: "slow reverse 3"}
+
+// IsValid - whether the mode is defined by the standard
+func (m PlayMode) IsValid() bool {
+	_, ok := playModeNames[m]
+	return ok
+}
+
+func (m PlayMode) String() string {
+	if name, ok := playModeNames[m]; ok {
+		return name
+	}
+	return "Unknown"
+}
+
+// StatusRequest - the operand of Give Deck Status (and Give Tuner Device
+// Status): report the status once, on every change or stop reporting
+type StatusRequest byte
+
+// status requests as used on the bus
+const (
+	StatusRequestOn   StatusRequest = 0x01
+	StatusRequestOff  StatusRequest = 0x02
+	StatusRequestOnce StatusRequest = 0x03
+)
+
+var statusRequestNames = map[StatusRequest]string{StatusRequestOn: "on",
+	StatusRequestOff: "off", StatusRequestOnce: "once"}
+
+// IsValid - whether the request is defined by the standard
+func (r StatusRequest) IsValid() bool {
+	_, ok := statusRequestNames[r]
+	return ok
+}
+
+func (r StatusRequest) String() string {
+	if name, ok := statusRequestNames[r]; ok {
+		return name
+	}
+	return "Unknown"
+}
+
+// DeckInfo - the operand of Deck Status
+type DeckInfo byte
+
+// deck info as used on the bus
+const (
+	DeckInfoPlay               DeckInfo = 0x11
+	DeckInfoRecord             DeckInfo = 0x12
+	DeckInfoPlayReverse        DeckInfo = 0x13
+	DeckInfoStill              DeckInfo = 0x14
+	DeckInfoSlow               DeckInfo = 0x15
+	DeckInfoSlowReverse        DeckInfo = 0x16
+	DeckInfoFastForward        DeckInfo = 0x17
+	DeckInfoFastReverse        DeckInfo = 0x18
+	DeckInfoNoMedia            DeckInfo = 0x19
+	DeckInfoStop               DeckInfo = 0x1A
+	DeckInfoSkipForward        DeckInfo = 0x1B
+	DeckInfoSkipReverse        DeckInfo = 0x1C
+	DeckInfoIndexSearchForward DeckInfo = 0x1D
+	DeckInfoIndexSearchReverse DeckInfo = 0x1E
+	DeckInfoOtherStatus        DeckInfo = 0x1F
+)
+
+var deckInfoNames = map[DeckInfo]string{DeckInfoPlay: "play",
+	DeckInfoRecord: "record", DeckInfoPlayReverse: "play reverse",
+	DeckInfoStill: "still", DeckInfoSlow: "slow",
+	DeckInfoSlowReverse: "slow reverse", DeckInfoFastForward: "fast forward",
+	DeckInfoFastReverse: "fast reverse", DeckInfoNoMedia: "no media",
+	DeckInfoStop: "stop", DeckInfoSkipForward: "skip forward",
+	DeckInfoSkipReverse: "skip reverse", DeckInfoOtherStatus: "other status",
+	DeckInfoIndexSearchForward: "index search forward",
+	DeckInfoIndexSearchReverse: "index search reverse"}
+
+// IsValid - whether the info is defined by the standard
+func (i DeckInfo) IsValid() bool {
+	_, ok := deckInfoNames[i]
+	return ok
+}
+
+func (i DeckInfo) String() string {
+	if name, ok := deckInfoNames[i]; ok {
+		return name
+	}
+	return "Unknown"
+}
+
+// DeckControl - control the deck of a playback or recording device (skip,
+// stop, eject)
+func (c *Connection) DeckControl(address LogicalAddress, mode DeckControlMode) error {
+	return c.Send(address, DeckControl{Mode: mode})
+}
+
+// Play - start playback in the given mode (forward, reverse, still, fast
+// or slow)
+func (c *Connection) Play(address LogicalAddress, mode PlayMode) error {
+	return c.Send(address, Play{Mode: mode})
+}
+
+// GiveDeckStatus - ask a device for its deck status once or on every
+// change (StatusRequestOn, the later reports are published as commands,
+// see OnDeckStatus) and returns the first report. StatusRequestOff stops
+// the reports and returns no status.
+func (c *Connection) GiveDeckStatus(ctx context.Context, address LogicalAddress, request StatusRequest) (DeckInfo, error) {
+	if request == StatusRequestOff {
+		return 0, c.SendContext(ctx, address, GiveDeckStatus{Request: request})
+	}
+
+	reply, err := c.Request(ctx, address, GiveDeckStatus{Request: request})
+	if err != nil {
+		return 0, err
+	}
+	status, ok := reply.(DeckStatus)
+	if !ok {
+		return 0, unexpectedReply(OpGiveDeckStatus, reply)
+	}
+	return status.Info, nil
+}
+
+// OnDeckStatus - call fn for every Deck Status received
+func (c *Connection) OnDeckStatus(fn func(source LogicalAddress, status DeckInfo)) *Subscription {
+	return c.OnCommand(func(cmd Command) {
+		if m, ok := cmd.Message.(DeckStatus); ok {
+			fn(cmd.Initiator, m.Info)
+		}
+	}, OpDeckStatus)
+}
@@ -0,0 +1,37 @@
+package cec_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/chbmuc/cec"
+	"github.com/chbmuc/cec/cectest"
+)
+
+func TestDeck(t *testing.T) {
+	c, _ := openBus(t, cectest.NewTV(), cectest.NewPlayback(8, 0x2000, "Player"))
+	ctx := context.Background()
+
+	if info, err := c.GiveDeckStatus(ctx, cec.Playback2, cec.StatusRequestOnce); err != nil || info != cec.DeckInfoStop {
+		t.Errorf("GiveDeckStatus = %v, %v, want stop", info, err)
+	}
+
+	if err := c.Play(cec.Playback2, cec.PlayForward); err != nil {
+		t.Fatalf("Play: %v", err)
+	}
+	if info, err := c.GiveDeckStatus(ctx, cec.Playback2, cec.StatusRequestOnce); err != nil || info != cec.DeckInfoPlay {
+		t.Errorf("GiveDeckStatus after Play = %v, %v, want play", info, err)
+	}
+
+	if err := c.DeckControl(cec.Playback2, cec.DeckControlEject); err != nil {
+		t.Fatalf("DeckControl: %v", err)
+	}
+	if info, err := c.GiveDeckStatus(ctx, cec.Playback2, cec.StatusRequestOnce); err != nil || info != cec.DeckInfoNoMedia {
+		t.Errorf("GiveDeckStatus after eject = %v, %v, want no media", info, err)
+	}
+
+	// a device without a deck aborts the request
+	if _, err := c.GiveDeckStatus(ctx, cec.TV, cec.StatusRequestOnce); err == nil {
+		t.Error("GiveDeckStatus of the TV succeeded")
+	}
+}
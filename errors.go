@@ -0,0 +1,41 @@
+package cec
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrInvalidFrame - a frame, message or operand that cannot be encoded
+	// or decoded
+	ErrInvalidFrame = errors.New("cec: invalid frame")
+	// ErrNotAcknowledged - nobody acknowledged a frame, usually because
+	// there is no device at the destination
+	ErrNotAcknowledged = errors.New("cec: not acknowledged")
+	// ErrTimeout - the adapter or a device did not answer in time
+	ErrTimeout = errors.New("cec: timeout")
+	// ErrAdapterLost - the connection to the adapter is gone
+	ErrAdapterLost = errors.New("cec: adapter lost")
+	// ErrTransmitFailed - a frame could not be sent for another reason
+	// (arbitration lost, line error, libcec reporting a failure)
+	ErrTransmitFailed = errors.New("cec: transmit failed")
+)
+
+// FeatureAbortError - the destination answered a message with a Feature
+// Abort, use errors.As to get it
+type FeatureAbortError struct {
+	Opcode Opcode
+	Reason byte
+}
+
+// abort reasons of Feature Abort
+var abortReasons = []string{"unrecognized opcode", "not in correct mode to respond",
+	"cannot provide source", "invalid operand", "refused", "unable to determine"}
+
+func (e *FeatureAbortError) Error() string {
+	reason := "Unknown"
+	if int(e.Reason) < len(abortReasons) {
+		reason = abortReasons[e.Reason]
+	}
+	return fmt.Sprintf("feature abort for %s: %s", e.Opcode, reason)
+}
@@ -0,0 +1,124 @@
+package cec
+
+import (
+	"sync"
+)
+
+// OverflowPolicy - what happens to an event when the event buffer is full
+type OverflowPolicy int
+
+const (
+	// DropOldest - discard the oldest buffered event to make room
+	DropOldest OverflowPolicy = iota
+	// DropNewest - discard the event that does not fit
+	DropNewest
+	// Block - wait for the consumer. This stalls the backend (and libcec's
+	// own thread) until there is room.
+	Block
+)
+
+func (p OverflowPolicy) String() string {
+	switch p {
+	case DropOldest:
+		return "drop oldest"
+	case DropNewest:
+		return "drop newest"
+	case Block:
+		return "block"
+	}
+	return "Unknown"
+}
+
+// DefaultEventBufferSize - the number of events buffered per subscription
+// (and for the Events channel)
+const DefaultEventBufferSize = 1024
+
+// eventBuffer - bounded ring buffer between the backend callbacks and a
+// subscription
+type eventBuffer struct {
+	mu       sync.Mutex
+	notEmpty *sync.Cond
+	notFull  *sync.Cond
+	items    []interface{}
+	head     int
+	count    int
+	policy   OverflowPolicy
+	dropped  uint64
+	closed   bool
+}
+
+func newEventBuffer(size int, policy OverflowPolicy) *eventBuffer {
+	if size < 1 {
+		size = 1
+	}
+	b := &eventBuffer{items: make([]interface{}, size), policy: policy}
+	b.notEmpty = sync.NewCond(&b.mu)
+	b.notFull = sync.NewCond(&b.mu)
+	return b
+}
+
+// push - add an event, only blocks with the Block policy
+func (b *eventBuffer) push(event interface{}) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.policy == Block {
+		for b.count == len(b.items) && !b.closed {
+			b.notFull.Wait()
+		}
+	}
+	if b.closed {
+		return
+	}
+
+	if b.count == len(b.items) {
+		b.dropped++
+		if b.policy == DropNewest {
+			return
+		}
+		// drop the oldest
+		b.items[b.head] = nil
+		b.head = (b.head + 1) % len(b.items)
+		b.count--
+	}
+
+	b.items[(b.head+b.count)%len(b.items)] = event
+	b.count++
+	b.notEmpty.Signal()
+}
+
+// pop - wait for the next event, false once the buffer is closed
+func (b *eventBuffer) pop() (interface{}, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for b.count == 0 && !b.closed {
+		b.notEmpty.Wait()
+	}
+	if b.closed {
+		return nil, false
+	}
+
+	event := b.items[b.head]
+	b.items[b.head] = nil
+	b.head = (b.head + 1) % len(b.items)
+	b.count--
+	b.notFull.Signal()
+	return event, true
+}
+
+// close - drop the buffered events and wake everyone waiting
+func (b *eventBuffer) close() {
+	b.mu.Lock()
+	b.closed = true
+	b.notEmpty.Broadcast()
+	b.notFull.Broadcast()
+	b.mu.Unlock()
+}
+
+func (b *eventBuffer) stats() (buffered int, dropped uint64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.count, b.dropped
+}
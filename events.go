@@ -0,0 +1,165 @@
+package cec
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+type LogMessage struct {
+	Message                     string
+	Level                       string
+	Direction                   string
+	MillisecondsSinceConnection int64
+	Timestamp                   time.Time
+}
+
+type KeyPress struct {
+	KeyCode     UserControlCode
+	KeyCodeName string
+	Duration    int
+	Timestamp   time.Time
+}
+
+type DataPacket struct {
+	Data interface{}
+	Size int
+}
+
+type Command struct {
+	Initiator       LogicalAddress
+	Destination     LogicalAddress
+	Acknowledged    bool
+	EndOfMessage    bool
+	Opcode          Opcode
+	OpcodeName      string
+	Parameters      DataPacket
+	OpcodeSet       bool
+	TransmitTimeout int32
+	// Message - the decoded message, nil for polling messages. Messages
+	// that fail to decode are kept as RawMessage.
+	Message   Message
+	Timestamp time.Time
+	// VendorID, Vendor - for vendor messages the vendor ID of the initiator
+	// and the payload decoded by its VendorHandler (nil without handler)
+	VendorID uint32
+	Vendor   interface{}
+}
+
+type Parameter struct {
+	Type string
+	Data interface{}
+}
+
+type Alert struct {
+	Type       string
+	Parameters Parameter
+	Timestamp  time.Time
+}
+
+type MenuState struct {
+	Activated bool
+	Timestamp time.Time
+}
+
+type SourceActivated struct {
+	Source    LogicalAddress
+	Active    bool
+	Timestamp time.Time
+}
+
+// NewCommand - build the Command event for a raw frame received by a
+// backend
+func NewCommand(frame []byte) Command {
+	command := Command{
+		Acknowledged: true,
+		EndOfMessage: true,
+		Timestamp:    time.Now(),
+	}
+	if len(frame) == 0 {
+		return command
+	}
+
+	command.Initiator = LogicalAddress(frame[0] >> 4)
+	command.Destination = LogicalAddress(frame[0] & 0xF)
+	if len(frame) > 1 {
+		command.OpcodeSet = true
+		command.Opcode = Opcode(frame[1])
+		command.OpcodeName = command.Opcode.String()
+		command.Message = decodeCommand(command.Opcode, frame[2:])
+	}
+	if len(frame) > 2 {
+		params := append([]byte(nil), frame[2:]...)
+		command.Parameters = DataPacket{Data: params, Size: len(params)}
+	}
+	return command
+}
+
+// decodeCommand - decode a received message, falling back to RawMessage so
+// a malformed frame still reaches the consumer
+func decodeCommand(opcode Opcode, operands []byte) Message {
+	msg, err := Decode(opcode, operands)
+	if err != nil {
+		return RawMessage{Code: opcode, Operands: append([]byte(nil), operands...)}
+	}
+	return msg
+}
+
+// NewTrafficMessage - build the TRAFFIC log message libcec reports for a
+// frame sent (outbound) or received by a backend
+func NewTrafficMessage(frame []byte, outbound bool, sinceConnection time.Duration) LogMessage {
+	parts := make([]string, len(frame))
+	for i, b := range frame {
+		parts[i] = fmt.Sprintf("%02x", b)
+	}
+
+	prefix, direction := ">> ", "Inbound"
+	if outbound {
+		prefix, direction = "<< ", "Outbound"
+	}
+
+	return LogMessage{
+		Message:                     prefix + strings.Join(parts, ":"),
+		Level:                       "TRAFFIC",
+		Direction:                   direction,
+		MillisecondsSinceConnection: int64(sinceConnection / time.Millisecond),
+		Timestamp:                   time.Now(),
+	}
+}
+
+// DeviceAdded - a device showed up on the bus (answered a poll, sent a
+// message or was listed as active)
+type DeviceAdded struct {
+	Device    Device
+	Timestamp time.Time
+}
+
+// DeviceRemoved - a device did not answer a poll anymore or was
+// invalidated, Device is its last known state
+type DeviceRemoved struct {
+	Device    Device
+	Timestamp time.Time
+}
+
+// DeviceChanged - a cached field of a device changed ("OSDName",
+// "Vendor", "PowerStatus", "PhysicalAddress", "CECVersion",
+// "MenuLanguage" or "ActiveSource"), Device is the new state
+type DeviceChanged struct {
+	Device    Device
+	Field     string
+	Old       interface{}
+	New       interface{}
+	Timestamp time.Time
+}
+
+// SystemAudioModeChanged - System Audio Mode was turned on or off
+type SystemAudioModeChanged struct {
+	On        bool
+	Timestamp time.Time
+}
+
+// ARCChanged - the Audio Return Channel was started or stopped
+type ARCChanged struct {
+	Active    bool
+	Timestamp time.Time
+}
@@ -0,0 +1,94 @@
+package cec_test
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/chbmuc/cec"
+	"github.com/chbmuc/cec/cectest"
+)
+
+func TestEventsUnread(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy cec.OverflowPolicy
+	}{
+		{name: "drop oldest", policy: cec.DropOldest},
+		{name: "block", policy: cec.Block},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bus := cectest.NewBus(cectest.NewTV())
+			c, err := cec.OpenBackend(bus.NewBackend(cectest.NewPlayback(4, 0x1000, "cec.go")),
+				cec.WithEventBuffer(2), cec.WithOverflowPolicy(tt.policy))
+			if err != nil {
+				t.Fatalf("OpenBackend: %v", err)
+			}
+			defer c.Destroy()
+
+			var commands int32
+			sub := c.OnCommand(func(cmd cec.Command) {
+				atomic.AddInt32(&commands, 1)
+			}, cec.OpStandby)
+			defer sub.Unsubscribe()
+
+			// nobody reads Events, that must neither hold up the
+			// subscription nor count as dropped. One command at a time,
+			// so the subscription keeps up.
+			for i := int32(1); i <= 5; i++ {
+				bus.Transmit([]byte{0x0F, 0x36})
+				deadline := time.Now().Add(time.Second)
+				for atomic.LoadInt32(&commands) < i && time.Now().Before(deadline) {
+					time.Sleep(time.Millisecond)
+				}
+				if got := atomic.LoadInt32(&commands); got != i {
+					t.Fatalf("subscription got %d commands, want %d", got, i)
+				}
+			}
+			if got := c.DroppedEvents(); got != 0 {
+				t.Errorf("DroppedEvents = %d without a reader of Events", got)
+			}
+			if got := c.BufferedEvents(); got != 0 {
+				t.Errorf("BufferedEvents = %d without a reader of Events", got)
+			}
+		})
+	}
+}
+
+func TestEvents(t *testing.T) {
+	c, bus := openBus(t, cectest.NewTV())
+	events := c.Events()
+
+	bus.Transmit([]byte{0x0F, 0x36})
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case event := <-events:
+			if cmd, ok := event.(cec.Command); ok && cmd.Opcode == cec.OpStandby {
+				return
+			}
+		case <-timeout:
+			t.Fatal("no Standby command on the Events channel")
+		}
+	}
+}
+
+func TestEventsDestroy(t *testing.T) {
+	bus := cectest.NewBus(cectest.NewTV())
+	c, err := cec.OpenBackend(bus.NewBackend(cectest.NewPlayback(4, 0x1000, "cec.go")))
+	if err != nil {
+		t.Fatalf("OpenBackend: %v", err)
+	}
+	c.Destroy()
+
+	select {
+	case _, ok := <-c.Events():
+		if ok {
+			t.Error("event on the Events channel of a destroyed connection")
+		}
+	case <-time.After(time.Second):
+		t.Error("Events channel not closed by Destroy")
+	}
+}
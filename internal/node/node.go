@@ -0,0 +1,477 @@
+// Package node holds the part of a cec.Backend that does not depend on the
+// hardware: addressing, the high-level requests, tracking of the active
+// source and key presses, and the event queue. The linuxcec, pulse8 and
+// cectest backends embed a Node and supply a Transport for their hardware.
+package node
+
+import (
+	"sync"
+	"time"
+
+	"github.com/chbmuc/cec"
+)
+
+// Transport - puts frames on the bus
+type Transport interface {
+	// Transmit sends a frame, returns an error if it was not acknowledged
+	Transmit(frame []byte) error
+	// Request sends a frame and returns the operands of the reply with
+	// the given opcode
+	Request(frame []byte, reply byte) ([]byte, error)
+}
+
+// Node - the backend methods shared by the backends
+type Node struct {
+	transport Transport
+	started   time.Time
+
+	mu              sync.Mutex
+	logicalAddress  int
+	physicalAddress uint16
+	activeSource    int
+	osdName         string
+	vendorID        uint32
+	lastKey         cec.UserControlCode
+	lastKeyTime     time.Time
+
+	events  sync.Mutex
+	cond    *sync.Cond
+	queue   []interface{}
+	limit   int
+	policy  cec.OverflowPolicy
+	dropped uint64
+	handler func(event interface{})
+	closed  bool
+}
+
+// Control - the hooks a backend uses to drive its Node. They are kept off
+// Node so they do not become methods of the backend.
+type Control struct {
+	n *Node
+}
+
+// New - a node without a logical address that sends through transport,
+// and its control. Events are delivered until Control.Stop is called.
+func New(transport Transport) (*Node, *Control) {
+	n := &Node{
+		transport:      transport,
+		started:        time.Now(),
+		logicalAddress: 0xF,
+		activeSource:   -1,
+		limit:          cec.DefaultEventBufferSize,
+		policy:         cec.DropOldest,
+	}
+	n.cond = sync.NewCond(&n.events)
+
+	go n.deliver()
+
+	return n, &Control{n: n}
+}
+
+// LogicalAddress - the logical address claimed by the backend (15 if none)
+func (n *Node) LogicalAddress() int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return n.logicalAddress
+}
+
+func (n *Node) header(destination int) byte {
+	return byte(n.LogicalAddress()<<4 | destination&0xF)
+}
+
+// Transmit - send a raw frame, returns an error if it was not acknowledged.
+// Sending Active Source makes the backend the active source, like with
+// libcec.
+func (n *Node) Transmit(frame []byte) error {
+	n.traffic(frame, true)
+	if err := n.transport.Transmit(frame); err != nil {
+		return err
+	}
+
+	if len(frame) >= 2 && int(frame[0]>>4) == n.LogicalAddress() {
+		switch frame[1] {
+		case 0x82: // active source
+			n.setActiveSource(int(frame[0] >> 4))
+		case 0x9D: // inactive source
+			if n.IsActiveSource(int(frame[0] >> 4)) {
+				n.setActiveSource(-1)
+			}
+		}
+	}
+	return nil
+}
+
+// send - transmit a frame from the backend's logical address
+func (n *Node) send(destination int, opcode byte, params ...byte) error {
+	return n.Transmit(append([]byte{n.header(destination), opcode}, params...))
+}
+
+// request - send a request and return the operands of the reply
+func (n *Node) request(destination int, opcode byte, reply byte) ([]byte, error) {
+	frame := []byte{n.header(destination), opcode}
+	n.traffic(frame, true)
+	return n.transport.Request(frame, reply)
+}
+
+func (n *Node) PowerOn(address int) error {
+	if address == 0 || address == 0xF {
+		return n.send(0, 0x04)
+	}
+	if err := n.send(address, 0x44, 0x6D); err != nil {
+		return err
+	}
+	return n.send(address, 0x45)
+}
+
+func (n *Node) Standby(address int) error {
+	return n.send(address, 0x36)
+}
+
+// audioKey - send a user control key to the audio system, or the TV if
+// there is none
+func (n *Node) audioKey(key byte) error {
+	destination := 5
+	if !n.PollDevice(destination) {
+		destination = 0
+	}
+	if err := n.send(destination, 0x44, key); err != nil {
+		return err
+	}
+	return n.send(destination, 0x45)
+}
+
+func (n *Node) VolumeUp() error {
+	return n.audioKey(0x41)
+}
+
+func (n *Node) VolumeDown() error {
+	return n.audioKey(0x42)
+}
+
+func (n *Node) Mute() error {
+	return n.audioKey(0x43)
+}
+
+func (n *Node) KeyPress(address int, key int) error {
+	return n.send(address, 0x44, byte(key))
+}
+
+func (n *Node) KeyRelease(address int) error {
+	return n.send(address, 0x45)
+}
+
+// GetActiveDevices - poll every logical address
+func (n *Node) GetActiveDevices() [16]bool {
+	var devices [16]bool
+
+	own := n.LogicalAddress()
+	for address := 0; address < 15; address++ {
+		devices[address] = address == own || n.PollDevice(address)
+	}
+	return devices
+}
+
+func (n *Node) GetActiveSource() int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return n.activeSource
+}
+
+func (n *Node) IsActiveSource(address int) bool {
+	return n.GetActiveSource() == address
+}
+
+func (n *Node) PollDevice(address int) bool {
+	return n.Transmit([]byte{n.header(address)}) == nil
+}
+
+func (n *Node) GetDeviceOSDName(address int) string {
+	n.mu.Lock()
+	own, name := n.logicalAddress, n.osdName
+	n.mu.Unlock()
+	if address == own {
+		return name
+	}
+	params, err := n.request(address, 0x46, 0x47)
+	if err != nil {
+		return ""
+	}
+	return string(params)
+}
+
+func (n *Node) GetDeviceVendorID(address int) uint64 {
+	n.mu.Lock()
+	own, vendorID := n.logicalAddress, n.vendorID
+	n.mu.Unlock()
+	if address == own {
+		return uint64(vendorID)
+	}
+	params, err := n.request(address, 0x8C, 0x87)
+	if err != nil || len(params) < 3 {
+		return 0
+	}
+	return uint64(params[0])<<16 | uint64(params[1])<<8 | uint64(params[2])
+}
+
+func (n *Node) GetDevicePhysicalAddress(address int) uint16 {
+	n.mu.Lock()
+	own, pa := n.logicalAddress, n.physicalAddress
+	n.mu.Unlock()
+	if address == own {
+		return pa
+	}
+	params, err := n.request(address, 0x83, 0x84)
+	if err != nil || len(params) < 2 {
+		return 0xFFFF
+	}
+	return uint16(params[0])<<8 | uint16(params[1])
+}
+
+func (n *Node) GetDevicePowerStatus(address int) int {
+	if address == n.LogicalAddress() {
+		return 0x00
+	}
+	params, err := n.request(address, 0x8F, 0x90)
+	if err != nil || len(params) < 1 {
+		return 0x99
+	}
+	return int(params[0])
+}
+
+func (n *Node) GetAudioStatus() int {
+	params, err := n.request(5, 0x71, 0x7A)
+	if err != nil || len(params) < 1 {
+		return 0x7F
+	}
+	return int(params[0])
+}
+
+// SetEventHandler - set the function events are delivered to, events
+// received before are kept until then
+func (n *Node) SetEventHandler(handler func(event interface{})) {
+	n.events.Lock()
+	n.handler = handler
+	n.cond.Broadcast()
+	n.events.Unlock()
+}
+
+// SetEventQueue - the number of events queued for the event handler before
+// the policy applies (cec.DefaultEventBufferSize, cec.DropOldest by
+// default)
+func (n *Node) SetEventQueue(size int, policy cec.OverflowPolicy) {
+	if size < 1 {
+		size = 1
+	}
+
+	n.events.Lock()
+	n.limit = size
+	n.policy = policy
+	n.cond.Broadcast()
+	n.events.Unlock()
+}
+
+// DroppedEvents - the number of events lost because the queue was full
+func (n *Node) DroppedEvents() uint64 {
+	n.events.Lock()
+	defer n.events.Unlock()
+
+	return n.dropped
+}
+
+// traffic - report a frame as a TRAFFIC log message, the way libcec does
+func (n *Node) traffic(frame []byte, outbound bool) {
+	n.push(cec.NewTrafficMessage(frame, outbound, time.Since(n.started)))
+}
+
+// setActiveSource - track the active source, reporting when the backend
+// becomes or stops being it
+func (n *Node) setActiveSource(address int) {
+	n.mu.Lock()
+	own, previous := n.logicalAddress, n.activeSource
+	n.activeSource = address
+	n.mu.Unlock()
+
+	if previous == address {
+		return
+	}
+	if previous == own {
+		n.sourceActivated(own, false)
+	} else if address == own {
+		n.sourceActivated(own, true)
+	}
+}
+
+func (n *Node) sourceActivated(own int, active bool) {
+	n.push(cec.SourceActivated{
+		Source:    cec.LogicalAddress(own),
+		Active:    active,
+		Timestamp: time.Now(),
+	})
+}
+
+// push - queue an event, it is delivered from a separate goroutine so the
+// receive loop only waits for the consumer with the Block policy
+func (n *Node) push(event interface{}) {
+	n.events.Lock()
+	defer n.events.Unlock()
+
+	if n.policy == cec.Block {
+		for len(n.queue) >= n.limit && !n.closed {
+			n.cond.Wait()
+		}
+	}
+	if n.closed {
+		return
+	}
+
+	if len(n.queue) >= n.limit {
+		n.dropped++
+		if n.policy == cec.DropNewest {
+			return
+		}
+		n.queue = n.queue[1:]
+	}
+	n.queue = append(n.queue, event)
+	n.cond.Broadcast()
+}
+
+func (n *Node) deliver() {
+	for {
+		n.events.Lock()
+		for (len(n.queue) == 0 || n.handler == nil) && !n.closed {
+			n.cond.Wait()
+		}
+		if n.closed {
+			n.events.Unlock()
+			return
+		}
+		event := n.queue[0]
+		n.queue = n.queue[1:]
+		handler := n.handler
+		n.cond.Broadcast()
+		n.events.Unlock()
+
+		handler(event)
+	}
+}
+
+// SetAddresses - the logical address claimed by the backend (15 if none)
+// and the physical address of its HDMI port
+func (c *Control) SetAddresses(logical int, physical uint16) {
+	c.n.mu.Lock()
+	c.n.logicalAddress = logical
+	c.n.physicalAddress = physical
+	c.n.mu.Unlock()
+}
+
+// SetOSDName - the name the backend answers Give OSD Name with
+func (c *Control) SetOSDName(name string) {
+	c.n.mu.Lock()
+	c.n.osdName = name
+	c.n.mu.Unlock()
+}
+
+// SetVendorID - the vendor ID the backend reports for itself
+func (c *Control) SetVendorID(vendorID uint32) {
+	c.n.mu.Lock()
+	c.n.vendorID = vendorID
+	c.n.mu.Unlock()
+}
+
+// Send - transmit a frame from the backend's logical address
+func (c *Control) Send(destination int, opcode byte, params ...byte) error {
+	return c.n.send(destination, opcode, params...)
+}
+
+// Traffic - report a frame as a TRAFFIC log message
+func (c *Control) Traffic(frame []byte, outbound bool) {
+	c.n.traffic(frame, outbound)
+}
+
+// Push - queue an event for the event handler
+func (c *Control) Push(event interface{}) {
+	c.n.push(event)
+}
+
+// Handle - track the active source and key presses, answer Give Device
+// Power Status and Set Stream Path and report a received frame as callback
+// events. The backend reports the traffic and answers anything else its
+// hardware leaves to it before.
+func (c *Control) Handle(frame []byte) {
+	if len(frame) < 2 {
+		return
+	}
+
+	n := c.n
+	initiator := int(frame[0] >> 4)
+	destination := int(frame[0] & 0xF)
+	opcode := frame[1]
+	params := frame[2:]
+
+	n.mu.Lock()
+	own, pa := n.logicalAddress, n.physicalAddress
+	n.mu.Unlock()
+
+	switch opcode {
+	case 0x82: // active source
+		n.setActiveSource(initiator)
+	case 0x86: // set stream path
+		if len(params) >= 2 && uint16(params[0])<<8|uint16(params[1]) == pa {
+			n.send(0xF, 0x82, byte(pa>>8), byte(pa))
+		}
+	case 0x8F: // give device power status
+		if destination == own {
+			n.send(initiator, 0x90, 0x00)
+		}
+	case 0x44: // user control pressed
+		if len(params) >= 1 {
+			n.mu.Lock()
+			n.lastKey = cec.UserControlCode(params[0])
+			n.lastKeyTime = time.Now()
+			n.mu.Unlock()
+			n.push(cec.KeyPress{
+				KeyCode:     cec.UserControlCode(params[0]),
+				KeyCodeName: cec.UserControlCode(params[0]).String(),
+				Timestamp:   time.Now(),
+			})
+		}
+	case 0x45: // user control released
+		n.mu.Lock()
+		key, pressed := n.lastKey, n.lastKeyTime
+		n.lastKeyTime = time.Time{}
+		n.mu.Unlock()
+		if !pressed.IsZero() {
+			n.push(cec.KeyPress{
+				KeyCode:     key,
+				KeyCodeName: key.String(),
+				Duration:    int(time.Since(pressed) / time.Millisecond),
+				Timestamp:   time.Now(),
+			})
+		}
+	}
+
+	n.push(cec.NewCommand(frame))
+}
+
+// Stop - stop delivering events, false if the node was stopped before
+func (c *Control) Stop() bool {
+	c.n.events.Lock()
+	defer c.n.events.Unlock()
+
+	if c.n.closed {
+		return false
+	}
+	c.n.closed = true
+	c.n.cond.Broadcast()
+	return true
+}
+
+// Stopped - whether Stop was called
+func (c *Control) Stopped() bool {
+	c.n.events.Lock()
+	defer c.n.events.Unlock()
+
+	return c.n.closed
+}
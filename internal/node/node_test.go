@@ -0,0 +1,273 @@
+package node
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/chbmuc/cec"
+)
+
+// transport - records transmitted frames, every frame is acknowledged and
+// requests are answered from replies
+type transport struct {
+	mu      sync.Mutex
+	frames  [][]byte
+	replies map[byte][]byte
+}
+
+func (t *transport) Transmit(frame []byte) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.frames = append(t.frames, append([]byte(nil), frame...))
+	return nil
+}
+
+func (t *transport) Request(frame []byte, reply byte) ([]byte, error) {
+	t.Transmit(frame)
+	if params, ok := t.replies[reply]; ok {
+		return params, nil
+	}
+	return nil, cec.ErrTimeout
+}
+
+func (t *transport) sent() [][]byte {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return append([][]byte(nil), t.frames...)
+}
+
+// collector - collects the delivered events
+type collector struct {
+	mu     sync.Mutex
+	events []interface{}
+}
+
+func (c *collector) handle(event interface{}) {
+	c.mu.Lock()
+	c.events = append(c.events, event)
+	c.mu.Unlock()
+}
+
+// wait - wait briefly for an event matching fn
+func (c *collector) wait(t *testing.T, what string, fn func(event interface{}) bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		c.mu.Lock()
+		for _, event := range c.events {
+			if fn(event) {
+				c.mu.Unlock()
+				return
+			}
+		}
+		c.mu.Unlock()
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Errorf("no %s event", what)
+}
+
+func newNode(t *testing.T) (*Node, *Control, *transport, *collector) {
+	tr := &transport{replies: map[byte][]byte{0x47: []byte("TV"), 0x90: {0x01}}}
+	n, ctl := New(tr)
+	t.Cleanup(func() { ctl.Stop() })
+	ctl.SetAddresses(4, 0x1000)
+	ctl.SetOSDName("cec.go")
+	ctl.SetVendorID(0x001582)
+
+	c := &collector{}
+	n.SetEventHandler(c.handle)
+	return n, ctl, tr, c
+}
+
+func TestRequests(t *testing.T) {
+	n, _, tr, _ := newNode(t)
+
+	if got := n.GetDeviceOSDName(0); got != "TV" {
+		t.Errorf("OSD name = %q, want TV", got)
+	}
+	if got := n.GetDevicePowerStatus(0); got != 0x01 {
+		t.Errorf("power status = %#x, want standby", got)
+	}
+	if got := n.GetDevicePhysicalAddress(0); got != 0xFFFF {
+		t.Errorf("physical address without reply = %04x, want ffff", got)
+	}
+
+	// the own address is answered without a request
+	before := len(tr.sent())
+	if got := n.GetDeviceOSDName(4); got != "cec.go" {
+		t.Errorf("own OSD name = %q, want cec.go", got)
+	}
+	if got := n.GetDeviceVendorID(4); got != 0x001582 {
+		t.Errorf("own vendor ID = %06x, want 001582", got)
+	}
+	if got := n.GetDevicePhysicalAddress(4); got != 0x1000 {
+		t.Errorf("own physical address = %04x, want 1000", got)
+	}
+	if got := n.GetDevicePowerStatus(4); got != 0x00 {
+		t.Errorf("own power status = %#x, want on", got)
+	}
+	if after := len(tr.sent()); after != before {
+		t.Errorf("sent % x for the own address", tr.sent()[before:])
+	}
+}
+
+func TestHandle(t *testing.T) {
+	n, ctl, tr, c := newNode(t)
+
+	ctl.Handle([]byte{0x04, 0x8F})
+	ctl.Handle([]byte{0x08, 0x8F}) // for another device
+	ctl.Handle([]byte{0x0F, 0x86, 0x10, 0x00})
+	want := [][]byte{{0x40, 0x90, 0x00}, {0x4F, 0x82, 0x10, 0x00}}
+	if got := tr.sent(); len(got) != len(want) || !bytes.Equal(got[0], want[0]) || !bytes.Equal(got[1], want[1]) {
+		t.Errorf("sent % x, want % x", got, want)
+	}
+	if got := n.GetActiveSource(); got != 4 {
+		t.Errorf("active source = %d, want 4", got)
+	}
+	c.wait(t, "SourceActivated", func(event interface{}) bool {
+		s, ok := event.(cec.SourceActivated)
+		return ok && s.Active && s.Source == 4
+	})
+
+	ctl.Handle([]byte{0x0F, 0x82, 0x20, 0x00})
+	if !n.IsActiveSource(0) {
+		t.Errorf("active source = %d, want 0", n.GetActiveSource())
+	}
+	c.wait(t, "inactive SourceActivated", func(event interface{}) bool {
+		s, ok := event.(cec.SourceActivated)
+		return ok && !s.Active
+	})
+
+	ctl.Handle([]byte{0x04, 0x44, 0x41})
+	ctl.Handle([]byte{0x04, 0x45})
+	c.wait(t, "Command", func(event interface{}) bool {
+		cmd, ok := event.(cec.Command)
+		return ok && cmd.Opcode == cec.OpUserControlReleased
+	})
+	// the key events are queued before the command
+	presses := 0
+	c.mu.Lock()
+	for _, event := range c.events {
+		if k, ok := event.(cec.KeyPress); ok && k.KeyCode == 0x41 {
+			presses++
+		}
+	}
+	c.mu.Unlock()
+	if presses != 2 {
+		t.Errorf("%d KeyPress events, want one for pressing and one for releasing", presses)
+	}
+}
+
+func TestSendActiveSource(t *testing.T) {
+	n, _, _, c := newNode(t)
+
+	if err := n.Transmit([]byte{0x4F, 0x82, 0x10, 0x00}); err != nil {
+		t.Fatalf("Transmit: %v", err)
+	}
+	if got := n.GetActiveSource(); got != 4 {
+		t.Errorf("active source = %d after sending Active Source, want 4", got)
+	}
+	c.wait(t, "SourceActivated", func(event interface{}) bool {
+		s, ok := event.(cec.SourceActivated)
+		return ok && s.Active && s.Source == 4
+	})
+
+	if err := n.Transmit([]byte{0x40, 0x9D, 0x10, 0x00}); err != nil {
+		t.Fatalf("Transmit: %v", err)
+	}
+	if got := n.GetActiveSource(); got != -1 {
+		t.Errorf("active source = %d after sending Inactive Source, want none", got)
+	}
+	c.wait(t, "inactive SourceActivated", func(event interface{}) bool {
+		s, ok := event.(cec.SourceActivated)
+		return ok && !s.Active && s.Source == 4
+	})
+}
+
+func TestStop(t *testing.T) {
+	_, ctl, _, _ := newNode(t)
+
+	if ctl.Stopped() {
+		t.Fatal("stopped before Stop")
+	}
+	if !ctl.Stop() || !ctl.Stopped() {
+		t.Error("Stop did not stop the node")
+	}
+	if ctl.Stop() {
+		t.Error("second Stop reported true")
+	}
+}
+
+func TestEventQueue(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy cec.OverflowPolicy
+		want   []int // the events delivered
+	}{
+		{name: "drop oldest", policy: cec.DropOldest, want: []int{3, 4}},
+		{name: "drop newest", policy: cec.DropNewest, want: []int{0, 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n, ctl := New(&transport{})
+			defer ctl.Stop()
+			n.SetEventQueue(2, tt.policy)
+
+			// queued until there is a handler
+			for i := 0; i < 5; i++ {
+				ctl.Push(i)
+			}
+			if got := n.DroppedEvents(); got != 3 {
+				t.Errorf("DroppedEvents = %d, want 3", got)
+			}
+
+			c := &collector{}
+			n.SetEventHandler(c.handle)
+			c.wait(t, "last", func(event interface{}) bool {
+				return event == tt.want[len(tt.want)-1]
+			})
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			if len(c.events) != len(tt.want) || c.events[0] != tt.want[0] {
+				t.Errorf("delivered %v, want %v", c.events, tt.want)
+			}
+		})
+	}
+}
+
+func TestEventQueueBlock(t *testing.T) {
+	n, ctl := New(&transport{})
+	defer ctl.Stop()
+	n.SetEventQueue(1, cec.Block)
+
+	pushed := make(chan struct{})
+	go func() {
+		ctl.Push(0)
+		ctl.Push(1)
+		close(pushed)
+	}()
+
+	select {
+	case <-pushed:
+		t.Fatal("Push did not wait for room in the queue")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	c := &collector{}
+	n.SetEventHandler(c.handle)
+	select {
+	case <-pushed:
+	case <-time.After(time.Second):
+		t.Fatal("Push still waiting after the queue was drained")
+	}
+	c.wait(t, "second", func(event interface{}) bool { return event == 1 })
+	if got := n.DroppedEvents(); got != 0 {
+		t.Errorf("DroppedEvents = %d with Block", got)
+	}
+}
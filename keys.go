@@ -0,0 +1,127 @@
+package cec
+
+import (
+	"fmt"
+)
+
+// UserControlCode - a remote control key (the operand of User Control
+// Pressed)
+type UserControlCode byte
+
+// user control codes
+const (
+	KeySelect                 UserControlCode = 0x00
+	KeyUp                     UserControlCode = 0x01
+	KeyDown                   UserControlCode = 0x02
+	KeyLeft                   UserControlCode = 0x03
+	KeyRight                  UserControlCode = 0x04
+	KeyRightUp                UserControlCode = 0x05
+	KeyRightDown              UserControlCode = 0x06
+	KeyLeftUp                 UserControlCode = 0x07
+	KeyLeftDown               UserControlCode = 0x08
+	KeyRootMenu               UserControlCode = 0x09
+	KeySetupMenu              UserControlCode = 0x0A
+	KeyContentsMenu           UserControlCode = 0x0B
+	KeyFavoriteMenu           UserControlCode = 0x0C
+	KeyExit                   UserControlCode = 0x0D
+	Key0                      UserControlCode = 0x20
+	Key1                      UserControlCode = 0x21
+	Key2                      UserControlCode = 0x22
+	Key3                      UserControlCode = 0x23
+	Key4                      UserControlCode = 0x24
+	Key5                      UserControlCode = 0x25
+	Key6                      UserControlCode = 0x26
+	Key7                      UserControlCode = 0x27
+	Key8                      UserControlCode = 0x28
+	Key9                      UserControlCode = 0x29
+	KeyDot                    UserControlCode = 0x2A
+	KeyEnter                  UserControlCode = 0x2B
+	KeyClear                  UserControlCode = 0x2C
+	KeyNextFavorite           UserControlCode = 0x2F
+	KeyChannelUp              UserControlCode = 0x30
+	KeyChannelDown            UserControlCode = 0x31
+	KeyPreviousChannel        UserControlCode = 0x32
+	KeySoundSelect            UserControlCode = 0x33
+	KeyInputSelect            UserControlCode = 0x34
+	KeyDisplayInformation     UserControlCode = 0x35
+	KeyHelp                   UserControlCode = 0x36
+	KeyPageUp                 UserControlCode = 0x37
+	KeyPageDown               UserControlCode = 0x38
+	KeyPower                  UserControlCode = 0x40
+	KeyVolumeUp               UserControlCode = 0x41
+	KeyVolumeDown             UserControlCode = 0x42
+	KeyMute                   UserControlCode = 0x43
+	KeyPlay                   UserControlCode = 0x44
+	KeyStop                   UserControlCode = 0x45
+	KeyPause                  UserControlCode = 0x46
+	KeyRecord                 UserControlCode = 0x47
+	KeyRewind                 UserControlCode = 0x48
+	KeyFastForward            UserControlCode = 0x49
+	KeyEject                  UserControlCode = 0x4A
+	KeyForward                UserControlCode = 0x4B
+	KeyBackward               UserControlCode = 0x4C
+	KeyStopRecord             UserControlCode = 0x4D
+	KeyPauseRecord            UserControlCode = 0x4E
+	KeyAngle                  UserControlCode = 0x50
+	KeySubPicture             UserControlCode = 0x51
+	KeyVideoOnDemand          UserControlCode = 0x52
+	KeyElectronicProgramGuide UserControlCode = 0x53
+	KeyTimerProgramming       UserControlCode = 0x54
+	KeyInitialConfiguration   UserControlCode = 0x55
+	KeyPlayFunction           UserControlCode = 0x60
+	KeyPausePlay              UserControlCode = 0x61
+	KeyRecordFunction         UserControlCode = 0x62
+	KeyPauseRecordFunction    UserControlCode = 0x63
+	KeyStopFunction           UserControlCode = 0x64
+	KeyMuteFunction           UserControlCode = 0x65
+	KeyRestoreVolume          UserControlCode = 0x66
+	KeyTune                   UserControlCode = 0x67
+	KeySelectMedia            UserControlCode = 0x68
+	KeySelectAvInput          UserControlCode = 0x69
+	KeySelectAudioInput       UserControlCode = 0x6A
+	KeyPowerToggle            UserControlCode = 0x6B
+	KeyPowerOff               UserControlCode = 0x6C
+	KeyPowerOn                UserControlCode = 0x6D
+	KeyBlue                   UserControlCode = 0x71
+	KeyRed                    UserControlCode = 0x72
+	KeyGreen                  UserControlCode = 0x73
+	KeyYellow                 UserControlCode = 0x74
+	KeyF5                     UserControlCode = 0x75
+	KeyData                   UserControlCode = 0x76
+	KeyAnReturn               UserControlCode = 0x91
+)
+
+// IsValid - whether the key is defined by CEC
+func (k UserControlCode) IsValid() bool {
+	_, ok := keyList[k]
+	return ok
+}
+
+func (k UserControlCode) String() string {
+	if name, ok := keyList[k]; ok {
+		return name
+	}
+	return "Unknown"
+}
+
+// MarshalText - the name of the key ("VolumeUp"), keys without a name as
+// hex-code ("0x7e")
+func (k UserControlCode) MarshalText() ([]byte, error) {
+	if !k.IsValid() {
+		return []byte(fmt.Sprintf("0x%02x", byte(k))), nil
+	}
+	return []byte(k.String()), nil
+}
+
+// UnmarshalText - accepts key names (like GetKeyCodeByName) or numbers
+func (k *UserControlCode) UnmarshalText(text []byte) error {
+	if code := GetKeyCodeByName(string(text)); code >= 0 {
+		*k = UserControlCode(code)
+		return nil
+	}
+	if n, ok := parseNumber(string(text), 0xFF); ok {
+		*k = UserControlCode(n)
+		return nil
+	}
+	return fmt.Errorf("cec: unknown key %q", text)
+}
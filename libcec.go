@@ -1,12 +1,15 @@
+//go:build cgo && !nolibcec
+
 package cec
 
 /*
 #cgo pkg-config: libcec
 #include <stdio.h>
+#include <stdlib.h>
+#include <stdint.h>
 #include <errno.h>
 #include <libcec/cecc.h>
 
-ICECCallbacks g_callbacks;
 // callbacks.go exports
 void logMessageCallback(void *, const cec_log_message*);
 void keyPressCallback(void *, const cec_keypress*);
@@ -16,16 +19,24 @@ void alertCallback(void *, const libcec_alert, const libcec_parameter);
 int menuStateChangedCallback(void *, const cec_menu_state);
 void sourceActivatedCallback(void *, const cec_logical_address, uint8_t activated);
 
-void setupCallbacks(libcec_configuration *conf)
+// each connection gets its own callbacks, the handle identifies the
+// backend in the callbacks
+ICECCallbacks *setupCallbacks(libcec_configuration *conf, uintptr_t handle)
 {
-	g_callbacks.logMessage = &logMessageCallback;
-	g_callbacks.keyPress = &keyPressCallback;
-	g_callbacks.commandReceived = &commandCallback;
-	g_callbacks.configurationChanged = &configurationChangedCallback;
-	g_callbacks.alert = &alertCallback;
-	g_callbacks.menuStateChanged = &menuStateChangedCallback;
-	g_callbacks.sourceActivated = &sourceActivatedCallback;
-	(*conf).callbacks = &g_callbacks;
+	ICECCallbacks *callbacks = calloc(1, sizeof(ICECCallbacks));
+	if (callbacks == NULL)
+		return NULL;
+
+	callbacks->logMessage = &logMessageCallback;
+	callbacks->keyPress = &keyPressCallback;
+	callbacks->commandReceived = &commandCallback;
+	callbacks->configurationChanged = &configurationChangedCallback;
+	callbacks->alert = &alertCallback;
+	callbacks->menuStateChanged = &menuStateChangedCallback;
+	callbacks->sourceActivated = &sourceActivatedCallback;
+	(*conf).callbacks = callbacks;
+	(*conf).callbackParam = (void *) handle;
+	return callbacks;
 }
 
 void setName(libcec_configuration *conf, char *name)
@@ -53,16 +64,23 @@ void setLogicalAddress(cec_logical_addresses* addresses, cec_logical_address add
 import "C"
 
 import (
-	"encoding/hex"
 	"errors"
 	"fmt"
-	"log"
+	"runtime/cgo"
 	"strings"
+	"sync"
+	"unsafe"
 )
 
-// Connection class
-type Connection struct {
+// libcecBackend - Backend implementation on top of libcec
+type libcecBackend struct {
 	connection C.libcec_connection_t
+	callbacks  *C.ICECCallbacks
+	handle     cgo.Handle
+
+	mu      sync.Mutex
+	handler func(event interface{})
+	lost    bool
 }
 
 type cecAdapter struct {
@@ -70,9 +88,33 @@ type cecAdapter struct {
 	Comm string
 }
 
-var CallbackEvents chan interface{}
+// openLibcec - initialise libcec and open the adapter matching name
+func openLibcec(name, deviceName string, deviceType DeviceType) (Backend, error) {
+	b := &libcecBackend{}
+	b.handle = cgo.NewHandle(b)
+
+	err := b.cecInit(deviceName, deviceType)
+	if err != nil {
+		b.release()
+		return nil, err
+	}
+
+	adapter, err := getAdapter(b.connection, name)
+	if err != nil {
+		b.Close()
+		return nil, err
+	}
 
-func cecInit(deviceName, deviceType string) (C.libcec_connection_t, error) {
+	err = openAdapter(b.connection, adapter)
+	if err != nil {
+		b.Close()
+		return nil, err
+	}
+
+	return b, nil
+}
+
+func (b *libcecBackend) cecInit(deviceName string, deviceType DeviceType) error {
 	var connection C.libcec_connection_t
 	var conf C.libcec_configuration
 
@@ -81,17 +123,17 @@ func cecInit(deviceName, deviceType string) (C.libcec_connection_t, error) {
 	for i := 0; i < 5; i++ {
 		conf.deviceTypes.types[i] = C.CEC_DEVICE_TYPE_RESERVED
 	}
-	if deviceType == "tv" {
+	if deviceType == DeviceTypeTV {
 		conf.deviceTypes.types[0] = C.CEC_DEVICE_TYPE_TV
-	} else if deviceType == "recording" {
+	} else if deviceType == DeviceTypeRecording {
 		conf.deviceTypes.types[0] = C.CEC_DEVICE_TYPE_RECORDING_DEVICE
-	} else if deviceType == "reserved" {
+	} else if deviceType == DeviceTypeReserved {
 		conf.deviceTypes.types[0] = C.CEC_DEVICE_TYPE_RESERVED
-	} else if deviceType == "tuner" {
+	} else if deviceType == DeviceTypeTuner {
 		conf.deviceTypes.types[0] = C.CEC_DEVICE_TYPE_TUNER
-	} else if deviceType == "playback" {
+	} else if deviceType == DeviceTypePlayback {
 		conf.deviceTypes.types[0] = C.CEC_DEVICE_TYPE_PLAYBACK_DEVICE
-	} else if deviceType == "audio" {
+	} else if deviceType == DeviceTypeAudioSystem {
 		conf.deviceTypes.types[0] = C.CEC_DEVICE_TYPE_AUDIO_SYSTEM
 	} else {
 		conf.deviceTypes.types[0] = C.CEC_DEVICE_TYPE_RECORDING_DEVICE
@@ -99,14 +141,74 @@ func cecInit(deviceName, deviceType string) (C.libcec_connection_t, error) {
 
 	C.setName(&conf, C.CString(deviceName))
 
-	CallbackEvents = make(chan interface{})
-	C.setupCallbacks(&conf)
+	b.callbacks = C.setupCallbacks(&conf, C.uintptr_t(b.handle))
+	if b.callbacks == nil {
+		return errors.New("Failed to init CEC")
+	}
 
 	connection = C.libcec_initialise(&conf)
 	if connection == C.libcec_connection_t(nil) {
-		return connection, errors.New("Failed to init CEC")
+		return errors.New("Failed to init CEC")
+	}
+	b.connection = connection
+	return nil
+}
+
+// release - free the callbacks and the handle once libcec is gone
+func (b *libcecBackend) release() {
+	if b.callbacks != nil {
+		C.free(unsafe.Pointer(b.callbacks))
+		b.callbacks = nil
+	}
+	b.handle.Delete()
+}
+
+// failed - the error for a libcec call that returned failure
+func (b *libcecBackend) failed(op string) error {
+	b.mu.Lock()
+	lost := b.lost
+	b.mu.Unlock()
+
+	if lost {
+		return fmt.Errorf("%s: %w", op, ErrAdapterLost)
+	}
+	return fmt.Errorf("%s: %w", op, ErrTransmitFailed)
+}
+
+// lostAdapter - the error for a libcec call that does not report failure,
+// nil unless the adapter is gone
+func (b *libcecBackend) lostAdapter(op string) error {
+	b.mu.Lock()
+	lost := b.lost
+	b.mu.Unlock()
+
+	if lost {
+		return fmt.Errorf("%s: %w", op, ErrAdapterLost)
+	}
+	return nil
+}
+
+// backendFromParam - the backend a callback was registered for
+func backendFromParam(param unsafe.Pointer) *libcecBackend {
+	return cgo.Handle(uintptr(param)).Value().(*libcecBackend)
+}
+
+func (b *libcecBackend) SetEventHandler(handler func(event interface{})) {
+	b.mu.Lock()
+	b.handler = handler
+	b.mu.Unlock()
+}
+
+// emit - report an event to the handler, events before a handler is set
+// (while the adapter is opened) are dropped
+func (b *libcecBackend) emit(event interface{}) {
+	b.mu.Lock()
+	handler := b.handler
+	b.mu.Unlock()
+
+	if handler != nil {
+		handler(event)
 	}
-	return connection, nil
 }
 
 func getAdapter(connection C.libcec_connection_t, name string) (cecAdapter, error) {
@@ -139,16 +241,14 @@ func openAdapter(connection C.libcec_connection_t, adapter cecAdapter) error {
 	return nil
 }
 
-// Transmit CEC command - command is encoded as a hex string with
-// colons (e.g. "40:04")
-func (c *Connection) Transmit(command string) error {
+// Transmit - send a raw CEC frame
+func (b *libcecBackend) Transmit(cmd []byte) error {
 	var cecCommand C.cec_command
 
-	cmd, err := hex.DecodeString(removeSeparators(command))
-	if err != nil {
-		log.Fatal(err)
-	}
 	cmdLen := len(cmd)
+	if cmdLen == 0 || cmdLen > 16 {
+		return fmt.Errorf("%w: %d bytes", ErrInvalidFrame, cmdLen)
+	}
 
 	if cmdLen > 0 {
 		cecCommand.initiator = C.cec_logical_address((cmd[0] >> 4) & 0xF)
@@ -169,78 +269,68 @@ func (c *Connection) Transmit(command string) error {
 		}
 	}
 
-	result := C.libcec_transmit(c.connection, (*C.cec_command)(&cecCommand))
+	result := C.libcec_transmit(b.connection, (*C.cec_command)(&cecCommand))
 	if result < 1 {
-		return errors.New("Failed to transmit!")
+		return b.failed("cec_transmit")
 	}
 	return nil
 }
 
-// Destroy - destroy the cec connection
-func (c *Connection) Destroy() {
-	C.libcec_destroy(c.connection)
+// Close - destroy the libcec connection
+func (b *libcecBackend) Close() error {
+	C.libcec_destroy(b.connection)
+	b.release()
+	return nil
 }
 
-// PowerOn - power on the device with the given logical address
-func (c *Connection) PowerOn(address int) error {
-	if C.libcec_power_on_devices(c.connection, C.cec_logical_address(address)) != 1 {
-		return errors.New("Error in cec_power_on_devices")
+func (b *libcecBackend) PowerOn(address int) error {
+	if C.libcec_power_on_devices(b.connection, C.cec_logical_address(address)) != 1 {
+		return b.failed("cec_power_on_devices")
 	}
 	return nil
 }
 
-// Standby - put the device with the given address in standby mode
-func (c *Connection) Standby(address int) error {
-	if C.libcec_standby_devices(c.connection, C.cec_logical_address(address)) != 1 {
-		return errors.New("Error in cec_standby_devices")
+func (b *libcecBackend) Standby(address int) error {
+	if C.libcec_standby_devices(b.connection, C.cec_logical_address(address)) != 1 {
+		return b.failed("cec_standby_devices")
 	}
 	return nil
 }
 
-// VolumeUp - send a volume up command to the amp if present
-func (c *Connection) VolumeUp() error {
-	if C.libcec_volume_up(c.connection, 1) != 0 {
-		return errors.New("Error in cec_volume_up")
-	}
-	return nil
+// VolumeUp, VolumeDown and Mute - libcec returns the audio status reported
+// afterwards, not whether the key was sent
+func (b *libcecBackend) VolumeUp() error {
+	C.libcec_volume_up(b.connection, 1)
+	return b.lostAdapter("cec_volume_up")
 }
 
-// VolumeDown - send a volume down command to the amp if present
-func (c *Connection) VolumeDown() error {
-	if C.libcec_volume_down(c.connection, 1) != 0 {
-		return errors.New("Error in cec_volume_down")
-	}
-	return nil
+func (b *libcecBackend) VolumeDown() error {
+	C.libcec_volume_down(b.connection, 1)
+	return b.lostAdapter("cec_volume_down")
 }
 
-// Mute - send a mute/unmute command to the amp if present
-func (c *Connection) Mute() error {
-	if C.libcec_mute_audio(c.connection, 1) != 0 {
-		return errors.New("Error in cec_mute_audio")
-	}
-	return nil
+func (b *libcecBackend) Mute() error {
+	C.libcec_mute_audio(b.connection, 1)
+	return b.lostAdapter("cec_mute_audio")
 }
 
-// KeyPress - send a key press (down) command code to the given address
-func (c *Connection) KeyPress(address int, key int) error {
-	if C.libcec_send_keypress(c.connection, C.cec_logical_address(address), C.cec_user_control_code(key), 1) != 1 {
-		return errors.New("Error in cec_send_keypress")
+func (b *libcecBackend) KeyPress(address int, key int) error {
+	if C.libcec_send_keypress(b.connection, C.cec_logical_address(address), C.cec_user_control_code(key), 1) != 1 {
+		return b.failed("cec_send_keypress")
 	}
 	return nil
 }
 
-// KeyRelease - send a key releas command to the given address
-func (c *Connection) KeyRelease(address int) error {
-	if C.libcec_send_key_release(c.connection, C.cec_logical_address(address), 1) != 1 {
-		return errors.New("Error in cec_send_key_release")
+func (b *libcecBackend) KeyRelease(address int) error {
+	if C.libcec_send_key_release(b.connection, C.cec_logical_address(address), 1) != 1 {
+		return b.failed("cec_send_key_release")
 	}
 	return nil
 }
 
-// GetActiveDevices - returns an array of active devices
-func (c *Connection) GetActiveDevices() [16]bool {
+func (b *libcecBackend) GetActiveDevices() [16]bool {
 	var devices [16]bool
-	result := C.libcec_get_active_devices(c.connection)
+	result := C.libcec_get_active_devices(b.connection)
 
 	for i := 0; i < 16; i++ {
 		if int(result.addresses[i]) > 0 {
@@ -251,125 +341,48 @@ func (c *Connection) GetActiveDevices() [16]bool {
 	return devices
 }
 
-// GetActiveSource - returns the logical address of the currently active source
-func (c *Connection) GetActiveSource() int {
-	return int(C.libcec_get_active_source(c.connection))
-}
-
-// GetDeviceOSDName - get the OSD name of the specified device
-func (c *Connection) GetDeviceOSDName(address int) string {
-        var name *C.char = C.CString("")
- 	C.libcec_get_device_osd_name(c.connection, C.cec_logical_address(address), name)
-	return C.GoString(name)
+func (b *libcecBackend) GetActiveSource() int {
+	return int(C.libcec_get_active_source(b.connection))
 }
 
-// IsActiveSource - check if the device at the given address is the active source
-func (c *Connection) IsActiveSource(address int) bool {
-	result := C.libcec_is_active_source(c.connection, C.cec_logical_address(address))
-
-	if int(result) != 0 {
-		return true
-	}
+func (b *libcecBackend) IsActiveSource(address int) bool {
+	result := C.libcec_is_active_source(b.connection, C.cec_logical_address(address))
 
-	return false
+	return int(result) != 0
 }
 
-// GetDeviceVendorID - Get the Vendor-ID of the device at the given address
-func (c *Connection) GetDeviceVendorID(address int) uint64 {
-	result := C.libcec_get_device_vendor_id(c.connection, C.cec_logical_address(address))
+func (b *libcecBackend) PollDevice(address int) bool {
+	result := C.libcec_poll_device(b.connection, C.cec_logical_address(address))
 
-	return uint64(result)
-}
-
-// GetDevicePhysicalAddress - Get the physical address of the device at
-// the given logical address
-func (c *Connection) GetDevicePhysicalAddress(address int) string {
-	result := C.libcec_get_device_physical_address(c.connection, C.cec_logical_address(address))
-
-	return fmt.Sprintf("%x.%x.%x.%x", (uint(result)>>12)&0xf, (uint(result)>>8)&0xf, (uint(result)>>4)&0xf, uint(result)&0xf)
+	return (result != 0)
 }
 
-// GetDevicePowerStatus - Get the power status of the device at the
-// given address
-func (c *Connection) GetDevicePowerStatus(address int) string {
-	result := C.libcec_get_device_power_status(c.connection, C.cec_logical_address(address))
-
-	// C.CEC_POWER_STATUS_UNKNOWN == error
-
-	if int(result) == C.CEC_POWER_STATUS_ON {
-		return "on"
-	} else if int(result) == C.CEC_POWER_STATUS_STANDBY {
-		return "standby"
-	} else if int(result) == C.CEC_POWER_STATUS_IN_TRANSITION_STANDBY_TO_ON {
-		return "starting"
-	} else if int(result) == C.CEC_POWER_STATUS_IN_TRANSITION_ON_TO_STANDBY {
-		return "shutting down"
-	} else {
-		return ""
-	}
+func (b *libcecBackend) GetDeviceOSDName(address int) string {
+	var name [C.LIBCEC_OSD_NAME_SIZE]C.char
+	C.libcec_get_device_osd_name(b.connection, C.cec_logical_address(address), &name[0])
+	return C.GoString(&name[0])
 }
 
-func (c *Connection) GetAudioStatus() string {
-	result := C.libcec_audio_get_status(c.connection)
-
-	if int(result) == C.CEC_AUDIO_MUTE_STATUS_MASK {
-		return "MUTE"
-	} else if int(result) == C.CEC_AUDIO_VOLUME_STATUS_MASK {
-		return "MASK"
-	} else if int(result) == C.CEC_AUDIO_VOLUME_MIN {
-		return "0"
-	} else if int(result) == C.CEC_AUDIO_VOLUME_MAX {
-		return "100"
-	} else if int(result) == C.CEC_AUDIO_VOLUME_STATUS_UNKNOWN {
-		return "Unknown"
-	} else {
-		return "OTHER"
-	}
+func (b *libcecBackend) GetDeviceVendorID(address int) uint64 {
+	result := C.libcec_get_device_vendor_id(b.connection, C.cec_logical_address(address))
 
+	return uint64(result)
 }
 
-func (c *Connection) PollDevice(address int) bool {
-	result := C.libcec_poll_device(c.connection, C.cec_logical_address(address))
-
-	return (result != 0)
-}
+func (b *libcecBackend) GetDevicePhysicalAddress(address int) uint16 {
+	result := C.libcec_get_device_physical_address(b.connection, C.cec_logical_address(address))
 
-// GetVendorString - Get vendor string by ID
-func GetVendorString(id uint64) string {
-	var vendorName *C.char = C.CString("")
-	_, err := C.libcec_vendor_id_to_string(C.cec_vendor_id(id), vendorName, 10)
-	if err != nil {
-		return "Unknown"
-	}
-	return C.GoString(vendorName)
+	return uint16(result)
 }
 
-// GetOpcodeString - Get opcode string by hex
-func GetOpcodeString(opcode int) string {
-	var opcodeString *C.char = C.CString("")
-	_, err := C.libcec_opcode_to_string(C.cec_opcode(opcode), opcodeString, 10)
-	if err != nil {
-		return "Unknown"
-	}
-	return C.GoString(opcodeString)
+func (b *libcecBackend) GetDevicePowerStatus(address int) int {
+	return int(C.libcec_get_device_power_status(b.connection, C.cec_logical_address(address)))
 }
 
-// GetUserControlKeyString - Get user control key string by int
-func GetUserControlKeyString(key C.cec_user_control_code) string {
-	var keyString *C.char = C.CString("")
-	_, err := C.libcec_user_control_key_to_string(key, keyString, 10)
-	if err != nil {
-		return "Unknown"
-	}
-	return C.GoString(keyString)
+func (b *libcecBackend) LogicalAddress() int {
+	return int(C.libcec_get_logical_addresses(b.connection).primary)
 }
 
-// GetLogicalNameByAddress - get logical name by address
-func GetLogicalNameByAddress(addr int) string {
-	var logicalName *C.char = C.CString("")
-	 _, err := C.libcec_logical_address_to_string(C.cec_logical_address(addr), logicalName, 10)
-	if err != nil {
-		return "Unknown"
-	}
-	return C.GoString(logicalName)
+func (b *libcecBackend) GetAudioStatus() int {
+	return int(C.libcec_audio_get_status(b.connection))
 }
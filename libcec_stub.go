@@ -0,0 +1,13 @@
+//go:build !cgo || nolibcec
+
+package cec
+
+import (
+	"errors"
+)
+
+// openLibcec - libcec is not available in this build (built without cgo
+// or with the nolibcec tag), use OpenBackend instead
+func openLibcec(name, deviceName string, deviceType DeviceType) (Backend, error) {
+	return nil, errors.New("libcec support not compiled in")
+}
@@ -0,0 +1,282 @@
+package linuxcec
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/chbmuc/cec"
+	"github.com/chbmuc/cec/internal/node"
+)
+
+// how long to wait for the reply to a request
+const replyTimeout = 1000
+
+// Config - how the adapter presents itself on the bus
+type Config struct {
+	// OSDName is reported in Set OSD Name (max 14 characters)
+	OSDName string
+	// DeviceType to claim a logical address for: TV, recording, tuner,
+	// playback or audio system (the zero value is cec.DeviceTypeTV)
+	DeviceType cec.DeviceType
+	// VendorID is reported in Device Vendor ID (0 for none)
+	VendorID uint32
+}
+
+// the kernel's logical address type and the All Device Types bit of each
+// device type the backend can claim an address for
+var deviceTypes = map[cec.DeviceType]struct {
+	logAddrType    byte
+	allDeviceTypes byte
+}{
+	cec.DeviceTypeTV:          {LogAddrTypeTV, 0x80},
+	cec.DeviceTypeRecording:   {LogAddrTypeRecord, 0x40},
+	cec.DeviceTypeTuner:       {LogAddrTypeTuner, 0x20},
+	cec.DeviceTypePlayback:    {LogAddrTypePlayback, 0x10},
+	cec.DeviceTypeAudioSystem: {LogAddrTypeAudioSystem, 0x08},
+}
+
+// Backend - cec.Backend implementation on top of the kernel CEC API
+type Backend struct {
+	*node.Node
+
+	device  Device
+	ctl     *node.Control
+	stopped chan struct{}
+}
+
+// Open - open the CEC device node at path (e.g. /dev/cec0)
+func Open(path string, config Config) (*Backend, error) {
+	device, err := OpenDevice(path)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := NewBackend(device, config)
+	if err != nil {
+		device.Close()
+		return nil, err
+	}
+	return b, nil
+}
+
+// NewBackend - configure the device (claim a logical address for the
+// configured device type) and start receiving messages
+func NewBackend(device Device, config Config) (*Backend, error) {
+	addrs, err := logAddrs(config)
+	if err != nil {
+		return nil, err
+	}
+
+	var caps Caps
+	if err := device.Capabilities(&caps); err != nil {
+		return nil, fmt.Errorf("linuxcec: CEC_ADAP_G_CAPS: %w", err)
+	}
+
+	if err := device.SetMode(ModeInitiator | ModeFollower); err != nil {
+		return nil, fmt.Errorf("linuxcec: CEC_S_MODE: %w", err)
+	}
+
+	if err := device.SetLogicalAddresses(&addrs); err != nil && err != syscall.EBUSY {
+		// EBUSY: the adapter is already configured, use its addresses
+		return nil, fmt.Errorf("linuxcec: CEC_ADAP_S_LOG_ADDRS: %w", err)
+	}
+	if err := device.LogicalAddresses(&addrs); err != nil {
+		return nil, fmt.Errorf("linuxcec: CEC_ADAP_G_LOG_ADDRS: %w", err)
+	}
+
+	k := &kernel{device: device}
+	b := &Backend{device: device, stopped: make(chan struct{})}
+	b.Node, b.ctl = node.New(k)
+	k.ctl = b.ctl
+
+	logical := 0xF
+	if addrs.NumLogAddrs > 0 && addrs.LogAddr[0] != LogAddrInvalid {
+		logical = int(addrs.LogAddr[0])
+	}
+	var physical uint16
+	if pa, err := device.PhysicalAddress(); err == nil {
+		physical = pa
+	}
+	b.ctl.SetAddresses(logical, physical)
+	osdName := config.OSDName
+	if osdName == "" {
+		osdName = strings.TrimRight(string(addrs.OSDName[:]), "\x00")
+	}
+	b.ctl.SetOSDName(osdName)
+	b.ctl.SetVendorID(config.VendorID)
+
+	go b.receive()
+
+	return b, nil
+}
+
+// logAddrs - the CEC_ADAP_S_LOG_ADDRS configuration for the given config
+func logAddrs(config Config) (LogAddrs, error) {
+	var addrs LogAddrs
+
+	dt, ok := deviceTypes[config.DeviceType]
+	if !ok {
+		return addrs, fmt.Errorf("linuxcec: unsupported device type %s", config.DeviceType)
+	}
+
+	addrs.NumLogAddrs = 1
+	addrs.CECVersion = 0x05
+	addrs.VendorID = config.VendorID
+	if config.VendorID == 0 {
+		addrs.VendorID = 0xffffffff
+	}
+	addrs.Flags = LogAddrsAllowUnregFallback
+	copy(addrs.OSDName[:14], config.OSDName)
+	addrs.PrimaryDeviceType[0] = byte(config.DeviceType)
+	addrs.LogAddrType[0] = dt.logAddrType
+	addrs.AllDeviceTypes[0] = dt.allDeviceTypes
+
+	return addrs, nil
+}
+
+// kernel - the node's transport, CEC_TRANSMIT on the device
+type kernel struct {
+	device Device
+	ctl    *node.Control
+}
+
+func (k *kernel) Transmit(frame []byte) error {
+	_, err := k.transmit(frame, 0)
+	return err
+}
+
+// Request - let the kernel wait for the reply, it matches the initiator
+// and handles Feature Abort
+func (k *kernel) Request(frame []byte, reply byte) ([]byte, error) {
+	msg, err := k.transmit(frame, reply)
+	if err != nil {
+		return nil, err
+	}
+	f := msg.Frame()
+	if len(f) < 2 {
+		return nil, fmt.Errorf("linuxcec: short reply: %w", cec.ErrInvalidFrame)
+	}
+	return f[2:], nil
+}
+
+// transmit - send a frame and, if reply is not 0, wait for the reply with
+// that opcode
+func (k *kernel) transmit(frame []byte, reply byte) (*Msg, error) {
+	if len(frame) == 0 || len(frame) > 16 {
+		return nil, fmt.Errorf("linuxcec: %w: %d bytes", cec.ErrInvalidFrame, len(frame))
+	}
+
+	msg := Msg{Len: uint32(len(frame))}
+	copy(msg.Msg[:], frame)
+	if reply != 0 {
+		msg.Reply = reply
+		msg.Timeout = replyTimeout
+	}
+
+	if err := k.device.Transmit(&msg); err != nil {
+		if errors.Is(err, syscall.ENODEV) {
+			return nil, fmt.Errorf("linuxcec: CEC_TRANSMIT: %w", cec.ErrAdapterLost)
+		}
+		return nil, fmt.Errorf("linuxcec: CEC_TRANSMIT: %w", err)
+	}
+	if msg.TxStatus&TxStatusOK == 0 {
+		return nil, txError(msg.TxStatus)
+	}
+	if reply != 0 {
+		if msg.RxStatus&RxStatusFeatureAbort != 0 {
+			abort := &cec.FeatureAbortError{Opcode: cec.Opcode(frame[1]), Reason: 5}
+			if f := msg.Frame(); len(f) >= 4 {
+				abort.Reason = f[3]
+			}
+			return nil, abort
+		}
+		if msg.RxStatus&RxStatusOK == 0 {
+			return nil, fmt.Errorf("linuxcec: no reply: %w", cec.ErrTimeout)
+		}
+		k.ctl.Traffic(msg.Frame(), false)
+	}
+	return &msg, nil
+}
+
+func txError(status byte) error {
+	switch {
+	case status&TxStatusNack != 0:
+		return fmt.Errorf("linuxcec: %w", cec.ErrNotAcknowledged)
+	case status&TxStatusArbLost != 0:
+		return fmt.Errorf("linuxcec: arbitration lost: %w", cec.ErrTransmitFailed)
+	case status&TxStatusTimeout != 0:
+		return fmt.Errorf("linuxcec: %w", cec.ErrTimeout)
+	case status&TxStatusAborted != 0:
+		return fmt.Errorf("linuxcec: aborted: %w", cec.ErrTransmitFailed)
+	}
+	return fmt.Errorf("linuxcec: status %#02x: %w", status, cec.ErrTransmitFailed)
+}
+
+// Close - stop receiving and close the device
+func (b *Backend) Close() error {
+	if !b.ctl.Stop() {
+		return nil
+	}
+
+	<-b.stopped
+	return b.device.Close()
+}
+
+// receive - read messages and events until the backend is closed
+func (b *Backend) receive() {
+	defer close(b.stopped)
+
+	for !b.ctl.Stopped() {
+		message, event, err := b.device.Wait(100 * time.Millisecond)
+		if err != nil {
+			b.ctl.Push(cec.Alert{Type: "CONNECTION_LOST", Parameters: cec.Parameter{Type: "STRING", Data: err.Error()}, Timestamp: time.Now()})
+			return
+		}
+
+		if event {
+			var ev Event
+			if b.device.DequeueEvent(&ev) == nil {
+				b.handleEvent(&ev)
+			}
+		}
+		if message {
+			msg := Msg{Timeout: 100}
+			if b.device.Receive(&msg) == nil && msg.Len > 0 {
+				// the kernel answers Give Physical Address, Give OSD Name,
+				// Give Device Vendor ID, Get CEC Version and Abort itself
+				b.ctl.Traffic(msg.Frame(), false)
+				b.ctl.Handle(msg.Frame())
+			}
+		}
+	}
+}
+
+func (b *Backend) handleEvent(ev *Event) {
+	switch ev.Event {
+	case EventStateChange:
+		pa, mask := ev.StateChange()
+
+		logical := 0xF
+		for address := 0; address < 15; address++ {
+			if mask&(1<<uint(address)) != 0 {
+				logical = address
+				break
+			}
+		}
+		b.ctl.SetAddresses(logical, pa)
+
+		if pa == 0xFFFF {
+			b.ctl.Push(cec.Alert{Type: "PHYSICAL_ADDRESS_ERROR", Timestamp: time.Now()})
+		}
+	case EventLostMsgs:
+		b.ctl.Push(cec.LogMessage{
+			Message:   fmt.Sprintf("lost %d messages", ev.LostMsgs()),
+			Level:     "WARNING",
+			Direction: "N/A",
+			Timestamp: time.Now(),
+		})
+	}
+}
@@ -0,0 +1,318 @@
+package linuxcec
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/chbmuc/cec"
+)
+
+// events - collects the events of a backend
+type events struct {
+	mu   sync.Mutex
+	list []interface{}
+}
+
+func (e *events) handle(event interface{}) {
+	e.mu.Lock()
+	e.list = append(e.list, event)
+	e.mu.Unlock()
+}
+
+// find - wait briefly for an event matching fn
+func (e *events) find(fn func(event interface{}) bool) bool {
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		e.mu.Lock()
+		for _, event := range e.list {
+			if fn(event) {
+				e.mu.Unlock()
+				return true
+			}
+		}
+		e.mu.Unlock()
+		time.Sleep(5 * time.Millisecond)
+	}
+	return false
+}
+
+func open(t *testing.T, device *fakeDevice, config Config) (*Backend, *events) {
+	t.Helper()
+
+	b, err := NewBackend(device, config)
+	if err != nil {
+		t.Fatalf("NewBackend: %v", err)
+	}
+	t.Cleanup(func() { b.Close() })
+
+	e := &events{}
+	b.SetEventHandler(e.handle)
+	return b, e
+}
+
+// waitFrame - wait briefly for the backend to transmit frame
+func waitFrame(t *testing.T, device *fakeDevice, frame []byte) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		for _, f := range device.frames() {
+			if bytes.Equal(f, frame) {
+				return
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("% x not transmitted, got % x", frame, device.frames())
+}
+
+func TestClaim(t *testing.T) {
+	device := newFakeDevice(0x1000)
+	device.taken[4] = true
+	b, _ := open(t, device, Config{OSDName: "cec.go", DeviceType: cec.DeviceTypePlayback})
+
+	if got := b.LogicalAddress(); got != 8 {
+		t.Errorf("logical address = %d, want 8 (4 is taken)", got)
+	}
+	if got := b.GetDevicePhysicalAddress(8); got != 0x1000 {
+		t.Errorf("own physical address = %04x, want 1000", got)
+	}
+	if got := b.GetDeviceOSDName(8); got != "cec.go" {
+		t.Errorf("own OSD name = %q, want cec.go", got)
+	}
+	if device.mode != ModeInitiator|ModeFollower {
+		t.Errorf("mode = %#x, want initiator and follower", device.mode)
+	}
+
+	addrs := device.addrs
+	if addrs.LogAddrType[0] != LogAddrTypePlayback || addrs.PrimaryDeviceType[0] != 0x04 {
+		t.Errorf("claimed type %d, primary device type %d, want playback", addrs.LogAddrType[0], addrs.PrimaryDeviceType[0])
+	}
+	if addrs.VendorID != 0xffffffff {
+		t.Errorf("vendor ID = %#x, want none", addrs.VendorID)
+	}
+	if name := string(bytes.TrimRight(addrs.OSDName[:], "\x00")); name != "cec.go" {
+		t.Errorf("configured OSD name = %q, want cec.go", name)
+	}
+}
+
+func TestClaimFallback(t *testing.T) {
+	device := newFakeDevice(0x1000)
+	device.taken[5] = true
+	b, _ := open(t, device, Config{DeviceType: cec.DeviceTypeAudioSystem})
+
+	if got := b.LogicalAddress(); got != 0xF {
+		t.Errorf("logical address = %d, want unregistered", got)
+	}
+}
+
+func TestClaimConfigured(t *testing.T) {
+	device := newFakeDevice(0x2000)
+	device.busy = true
+	device.addrs.NumLogAddrs = 1
+	device.addrs.LogAddr[0] = 3
+	copy(device.addrs.OSDName[:], "STB")
+	b, _ := open(t, device, Config{DeviceType: cec.DeviceTypePlayback})
+
+	if got := b.LogicalAddress(); got != 3 {
+		t.Errorf("logical address = %d, want the configured 3", got)
+	}
+	if got := b.GetDeviceOSDName(3); got != "STB" {
+		t.Errorf("OSD name = %q, want the configured STB", got)
+	}
+}
+
+func TestUnsupportedDeviceType(t *testing.T) {
+	for _, dt := range []cec.DeviceType{cec.DeviceTypeReserved, cec.DeviceTypeSwitch, 0x10} {
+		device := newFakeDevice(0x1000)
+		if b, err := NewBackend(device, Config{DeviceType: dt}); err == nil {
+			b.Close()
+			t.Errorf("NewBackend accepted device type %s", dt)
+		}
+		if device.addrs.NumLogAddrs != 0 {
+			t.Errorf("device type %s configured %d logical addresses", dt, device.addrs.NumLogAddrs)
+		}
+	}
+}
+
+func TestTransmitStatus(t *testing.T) {
+	tests := []struct {
+		name   string
+		status byte
+		err    error
+		want   error
+	}{
+		{name: "ok", status: TxStatusOK},
+		{name: "nack", status: TxStatusNack | TxStatusMaxRetries, want: cec.ErrNotAcknowledged},
+		{name: "arbitration lost", status: TxStatusArbLost | TxStatusMaxRetries, want: cec.ErrTransmitFailed},
+		{name: "timeout", status: TxStatusTimeout, want: cec.ErrTimeout},
+		{name: "aborted", status: TxStatusAborted, want: cec.ErrTransmitFailed},
+		{name: "low drive", status: TxStatusLowDrive | TxStatusMaxRetries, want: cec.ErrTransmitFailed},
+		{name: "device gone", err: syscall.ENODEV, want: cec.ErrAdapterLost},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			device := newFakeDevice(0x1000)
+			b, _ := open(t, device, Config{DeviceType: cec.DeviceTypePlayback})
+			device.mu.Lock()
+			device.err = tt.err
+			device.reply = func([]byte) (byte, []byte) { return tt.status, nil }
+			device.mu.Unlock()
+
+			err := b.Transmit([]byte{0x40, 0x04})
+			if tt.want == nil && err != nil || tt.want != nil && !errors.Is(err, tt.want) {
+				t.Errorf("Transmit = %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	device := newFakeDevice(0x1000)
+	b, _ := open(t, device, Config{DeviceType: cec.DeviceTypePlayback})
+	if err := b.Transmit(make([]byte, 17)); !errors.Is(err, cec.ErrInvalidFrame) {
+		t.Errorf("Transmit of 17 bytes = %v, want ErrInvalidFrame", err)
+	}
+}
+
+func TestRequests(t *testing.T) {
+	device := newFakeDevice(0x1000)
+	device.reply = func(frame []byte) (byte, []byte) {
+		if frame[0]&0xF == 0x3 {
+			return TxStatusNack, nil
+		}
+		if len(frame) < 2 {
+			return TxStatusOK, nil
+		}
+		switch frame[1] {
+		case 0x46:
+			return TxStatusOK, append([]byte{0x04, 0x47}, "TV"...)
+		case 0x8F:
+			return TxStatusOK, []byte{0x04, 0x90, 0x01}
+		case 0x83:
+			return TxStatusOK, []byte{0x0F, 0x84, 0x00, 0x00, 0x00}
+		case 0x8C:
+			return TxStatusOK, []byte{0x04, 0x00, 0x8C, 0x00} // feature abort
+		}
+		return TxStatusOK, nil
+	}
+	b, _ := open(t, device, Config{DeviceType: cec.DeviceTypePlayback})
+
+	if got := b.GetDeviceOSDName(0); got != "TV" {
+		t.Errorf("OSD name = %q, want TV", got)
+	}
+	if got := b.GetDevicePowerStatus(0); got != 0x01 {
+		t.Errorf("power status = %#x, want standby", got)
+	}
+	if got := b.GetDevicePhysicalAddress(0); got != 0x0000 {
+		t.Errorf("physical address = %04x, want 0000", got)
+	}
+	if got := b.GetDeviceVendorID(0); got != 0 {
+		t.Errorf("vendor ID after feature abort = %06x, want 0", got)
+	}
+	if got := b.GetDevicePowerStatus(3); got != 0x99 {
+		t.Errorf("power status of a missing device = %#x, want unknown", got)
+	}
+	if got := b.GetAudioStatus(); got != 0x7F {
+		t.Errorf("audio status without reply = %#x, want unknown", got)
+	}
+	if !b.PollDevice(0) || b.PollDevice(3) {
+		t.Error("PollDevice does not follow the acknowledgement")
+	}
+}
+
+func TestStateChange(t *testing.T) {
+	device := newFakeDevice(0x1000)
+	b, e := open(t, device, Config{DeviceType: cec.DeviceTypePlayback})
+
+	var ev Event
+	ev.SetStateChange(0x2100, 1<<8)
+	device.event(ev)
+	deadline := time.Now().Add(time.Second)
+	for b.LogicalAddress() != 8 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if got := b.LogicalAddress(); got != 8 {
+		t.Errorf("logical address after state change = %d, want 8", got)
+	}
+	if got := b.GetDevicePhysicalAddress(8); got != 0x2100 {
+		t.Errorf("physical address after state change = %04x, want 2100", got)
+	}
+
+	// unplugged: no physical address and no logical address
+	ev.SetStateChange(0xFFFF, 0)
+	device.event(ev)
+	if !e.find(func(event interface{}) bool {
+		a, ok := event.(cec.Alert)
+		return ok && a.Type == "PHYSICAL_ADDRESS_ERROR"
+	}) {
+		t.Error("no PHYSICAL_ADDRESS_ERROR alert")
+	}
+	if got := b.LogicalAddress(); got != 0xF {
+		t.Errorf("logical address after unplugging = %d, want unregistered", got)
+	}
+}
+
+func TestLostMessages(t *testing.T) {
+	device := newFakeDevice(0x1000)
+	_, e := open(t, device, Config{DeviceType: cec.DeviceTypePlayback})
+
+	ev := Event{Event: EventLostMsgs}
+	binary.NativeEndian.PutUint32(ev.Data[0:], 3)
+	device.event(ev)
+
+	if !e.find(func(event interface{}) bool {
+		m, ok := event.(cec.LogMessage)
+		return ok && m.Level == "WARNING" && m.Message == "lost 3 messages"
+	}) {
+		t.Error("no warning about the lost messages")
+	}
+}
+
+func TestBuiltinReplies(t *testing.T) {
+	device := newFakeDevice(0x1000)
+	b, e := open(t, device, Config{DeviceType: cec.DeviceTypePlayback})
+
+	// Give Device Power Status from the TV
+	device.deliver([]byte{0x04, 0x8F})
+	waitFrame(t, device, []byte{0x40, 0x90, 0x00})
+
+	// Set Stream Path to another device is ignored
+	device.deliver([]byte{0x0F, 0x86, 0x20, 0x00})
+	// Set Stream Path to the adapter makes it the active source
+	device.deliver([]byte{0x0F, 0x86, 0x10, 0x00})
+	waitFrame(t, device, []byte{0x4F, 0x82, 0x10, 0x00})
+	if !e.find(func(event interface{}) bool {
+		s, ok := event.(cec.SourceActivated)
+		return ok && s.Active && s.Source == 4
+	}) {
+		t.Error("no SourceActivated event")
+	}
+	if got := b.GetActiveSource(); got != 4 {
+		t.Errorf("active source = %d, want 4", got)
+	}
+	for _, f := range device.frames() {
+		if bytes.Equal(f, []byte{0x4F, 0x82, 0x20, 0x00}) {
+			t.Error("answered Set Stream Path for another device")
+		}
+	}
+
+	// another device becomes the active source
+	device.deliver([]byte{0x8F, 0x82, 0x20, 0x00})
+	if !e.find(func(event interface{}) bool {
+		s, ok := event.(cec.SourceActivated)
+		return ok && !s.Active
+	}) {
+		t.Error("no SourceActivated event for losing the active source")
+	}
+	if !e.find(func(event interface{}) bool {
+		cmd, ok := event.(cec.Command)
+		return ok && cmd.Opcode == cec.OpActiveSource && cmd.Initiator == 8
+	}) {
+		t.Error("no Command event for the Active Source")
+	}
+}
@@ -0,0 +1,169 @@
+// Package linuxcec is a cgo free backend for the cec package that talks to
+// the Linux kernel CEC framework (/dev/cecN) directly.
+//
+//	backend, err := linuxcec.Open("/dev/cec0", linuxcec.Config{OSDName: "cec.go", DeviceType: cec.DeviceTypePlayback})
+//	conn, err := cec.OpenBackend(backend)
+package linuxcec
+
+import (
+	"encoding/binary"
+	"time"
+)
+
+// modes for SetMode
+const (
+	ModeNoInitiator   = 0x00
+	ModeInitiator     = 0x01
+	ModeExclInitiator = 0x02
+	ModeNoFollower    = 0x00
+	ModeFollower      = 0x10
+	ModeExclFollower  = 0x20
+	ModeMonitor       = 0xe0
+	ModeMonitorAll    = 0xf0
+)
+
+// transmit status bits in Msg.TxStatus
+const (
+	TxStatusOK         = 1 << 0
+	TxStatusArbLost    = 1 << 1
+	TxStatusNack       = 1 << 2
+	TxStatusLowDrive   = 1 << 3
+	TxStatusError      = 1 << 4
+	TxStatusMaxRetries = 1 << 5
+	TxStatusAborted    = 1 << 6
+	TxStatusTimeout    = 1 << 7
+)
+
+// receive status bits in Msg.RxStatus
+const (
+	RxStatusOK           = 1 << 0
+	RxStatusTimeout      = 1 << 1
+	RxStatusFeatureAbort = 1 << 2
+	RxStatusAborted      = 1 << 3
+)
+
+// event types in Event.Event
+const (
+	EventStateChange = 1
+	EventLostMsgs    = 2
+)
+
+// logical address types in LogAddrs.LogAddrType
+const (
+	LogAddrTypeTV           = 0
+	LogAddrTypeRecord       = 1
+	LogAddrTypeTuner        = 2
+	LogAddrTypePlayback     = 3
+	LogAddrTypeAudioSystem  = 4
+	LogAddrTypeSpecific     = 5
+	LogAddrTypeUnregistered = 6
+)
+
+// LogAddrInvalid - value of an unclaimed entry in LogAddrs.LogAddr
+const LogAddrInvalid = 0xff
+
+// LogAddrsAllowUnregFallback - fall back to the unregistered address if no
+// logical address could be claimed
+const LogAddrsAllowUnregFallback = 1 << 0
+
+// Msg - struct cec_msg
+type Msg struct {
+	TxTimestamp     uint64
+	RxTimestamp     uint64
+	Len             uint32
+	Timeout         uint32
+	Sequence        uint32
+	Flags           uint32
+	Msg             [16]byte
+	Reply           byte
+	RxStatus        byte
+	TxStatus        byte
+	TxArbLostCount  byte
+	TxNackCount     byte
+	TxLowDriveCount byte
+	TxErrorCount    byte
+}
+
+// Frame - the bytes of the message
+func (m *Msg) Frame() []byte {
+	n := int(m.Len)
+	if n > len(m.Msg) {
+		n = len(m.Msg)
+	}
+	return append([]byte(nil), m.Msg[:n]...)
+}
+
+// Caps - struct cec_caps
+type Caps struct {
+	Driver            [32]byte
+	Name              [32]byte
+	AvailableLogAddrs uint32
+	Capabilities      uint32
+	Version           uint32
+}
+
+// LogAddrs - struct cec_log_addrs
+type LogAddrs struct {
+	LogAddr           [4]byte
+	LogAddrMask       uint16
+	CECVersion        byte
+	NumLogAddrs       byte
+	VendorID          uint32
+	Flags             uint32
+	OSDName           [15]byte
+	PrimaryDeviceType [4]byte
+	LogAddrType       [4]byte
+	AllDeviceTypes    [4]byte
+	Features          [4][12]byte
+}
+
+// Event - struct cec_event, Data holds the union payload
+type Event struct {
+	Timestamp uint64
+	Event     uint32
+	Flags     uint32
+	Data      [64]byte
+}
+
+// StateChange - payload of an EventStateChange event
+func (e *Event) StateChange() (physicalAddress uint16, logAddrMask uint16) {
+	return binary.NativeEndian.Uint16(e.Data[0:]), binary.NativeEndian.Uint16(e.Data[2:])
+}
+
+// SetStateChange - fill in the payload of an EventStateChange event
+func (e *Event) SetStateChange(physicalAddress uint16, logAddrMask uint16) {
+	e.Event = EventStateChange
+	binary.NativeEndian.PutUint16(e.Data[0:], physicalAddress)
+	binary.NativeEndian.PutUint16(e.Data[2:], logAddrMask)
+}
+
+// LostMsgs - payload of an EventLostMsgs event
+func (e *Event) LostMsgs() uint32 {
+	return binary.NativeEndian.Uint32(e.Data[0:])
+}
+
+// Device - the ioctl interface of a CEC device node. OpenDevice returns
+// the kernel implementation, tests can provide a fake.
+type Device interface {
+	// Capabilities - CEC_ADAP_G_CAPS
+	Capabilities(caps *Caps) error
+	// SetMode - CEC_S_MODE
+	SetMode(mode uint32) error
+	// PhysicalAddress - CEC_ADAP_G_PHYS_ADDR
+	PhysicalAddress() (uint16, error)
+	// LogicalAddresses - CEC_ADAP_G_LOG_ADDRS
+	LogicalAddresses(addrs *LogAddrs) error
+	// SetLogicalAddresses - CEC_ADAP_S_LOG_ADDRS
+	SetLogicalAddresses(addrs *LogAddrs) error
+	// Transmit - CEC_TRANSMIT, blocks until the message (and the reply,
+	// if msg.Reply is set) has been processed
+	Transmit(msg *Msg) error
+	// Receive - CEC_RECEIVE
+	Receive(msg *Msg) error
+	// DequeueEvent - CEC_DQEVENT
+	DequeueEvent(event *Event) error
+	// Wait - wait until a message or an event can be read, or the timeout
+	// expires
+	Wait(timeout time.Duration) (message bool, event bool, err error)
+	Close() error
+}
@@ -0,0 +1,122 @@
+package linuxcec
+
+import (
+	"os"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// ioctl request numbers from linux/cec.h
+const (
+	ioctlAdapGetCaps     = 0xc04c6100
+	ioctlAdapGetPhysAddr = 0x80026101
+	ioctlAdapGetLogAddrs = 0x805c6103
+	ioctlAdapSetLogAddrs = 0xc05c6104
+	ioctlTransmit        = 0xc0386105
+	ioctlReceive         = 0xc0386106
+	ioctlDequeueEvent    = 0xc0506107
+	ioctlSetMode         = 0x40046109
+)
+
+const (
+	pollIn  = 0x1
+	pollPri = 0x2
+	pollErr = 0x8
+	pollHup = 0x10
+)
+
+type pollFd struct {
+	fd      int32
+	events  int16
+	revents int16
+}
+
+// kernelDevice - Device implementation on top of a /dev/cecN file
+type kernelDevice struct {
+	file *os.File
+	fd   uintptr
+}
+
+// OpenDevice - open a CEC device node (e.g. /dev/cec0)
+func OpenDevice(path string) (Device, error) {
+	file, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return nil, err
+	}
+	return &kernelDevice{file: file, fd: file.Fd()}, nil
+}
+
+func (d *kernelDevice) ioctl(request uintptr, arg unsafe.Pointer) error {
+	for {
+		_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, d.fd, request, uintptr(arg))
+		if errno == syscall.EINTR {
+			continue
+		}
+		if errno != 0 {
+			return errno
+		}
+		return nil
+	}
+}
+
+func (d *kernelDevice) Capabilities(caps *Caps) error {
+	return d.ioctl(ioctlAdapGetCaps, unsafe.Pointer(caps))
+}
+
+func (d *kernelDevice) SetMode(mode uint32) error {
+	return d.ioctl(ioctlSetMode, unsafe.Pointer(&mode))
+}
+
+func (d *kernelDevice) PhysicalAddress() (uint16, error) {
+	var address uint16
+	err := d.ioctl(ioctlAdapGetPhysAddr, unsafe.Pointer(&address))
+	return address, err
+}
+
+func (d *kernelDevice) LogicalAddresses(addrs *LogAddrs) error {
+	return d.ioctl(ioctlAdapGetLogAddrs, unsafe.Pointer(addrs))
+}
+
+func (d *kernelDevice) SetLogicalAddresses(addrs *LogAddrs) error {
+	return d.ioctl(ioctlAdapSetLogAddrs, unsafe.Pointer(addrs))
+}
+
+func (d *kernelDevice) Transmit(msg *Msg) error {
+	return d.ioctl(ioctlTransmit, unsafe.Pointer(msg))
+}
+
+func (d *kernelDevice) Receive(msg *Msg) error {
+	return d.ioctl(ioctlReceive, unsafe.Pointer(msg))
+}
+
+func (d *kernelDevice) DequeueEvent(event *Event) error {
+	return d.ioctl(ioctlDequeueEvent, unsafe.Pointer(event))
+}
+
+// Wait - poll the file: POLLIN signals a message, POLLPRI an event
+func (d *kernelDevice) Wait(timeout time.Duration) (bool, bool, error) {
+	fds := []pollFd{{fd: int32(d.fd), events: pollIn | pollPri}}
+	ts := syscall.NsecToTimespec(int64(timeout))
+
+	for {
+		n, _, errno := syscall.Syscall6(syscall.SYS_PPOLL, uintptr(unsafe.Pointer(&fds[0])), 1, uintptr(unsafe.Pointer(&ts)), 0, 0, 0)
+		if errno == syscall.EINTR {
+			continue
+		}
+		if errno != 0 {
+			return false, false, errno
+		}
+		if n == 0 {
+			return false, false, nil
+		}
+		if fds[0].revents&(pollErr|pollHup) != 0 {
+			return false, false, syscall.ENODEV
+		}
+		return fds[0].revents&pollIn != 0, fds[0].revents&pollPri != 0, nil
+	}
+}
+
+func (d *kernelDevice) Close() error {
+	return d.file.Close()
+}
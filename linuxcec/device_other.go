@@ -0,0 +1,12 @@
+//go:build !linux
+
+package linuxcec
+
+import (
+	"errors"
+)
+
+// OpenDevice - the kernel CEC framework is only available on Linux
+func OpenDevice(path string) (Device, error) {
+	return nil, errors.New("linuxcec: not supported on this platform")
+}
@@ -0,0 +1,208 @@
+package linuxcec
+
+import (
+	"sync"
+	"syscall"
+	"time"
+)
+
+// fakeDevice - a Device emulating the kernel: it claims logical addresses,
+// answers transmits through reply and hands out queued messages and events
+type fakeDevice struct {
+	mu        sync.Mutex
+	physical  uint16
+	taken     map[byte]bool // logical addresses used by other devices
+	addrs     LogAddrs
+	busy      bool // already configured, CEC_ADAP_S_LOG_ADDRS fails
+	mode      uint32
+	sent      [][]byte
+	messages  []Msg
+	events    []Event
+	closed    bool
+	available chan struct{}
+
+	// reply - the transmit status and, for requests, the reply to a frame.
+	// Without it every frame is acknowledged and requests time out.
+	reply func(frame []byte) (txStatus byte, reply []byte)
+	// err - returned by Transmit instead of sending
+	err error
+}
+
+// logical addresses the kernel tries for each type
+var fakeCandidates = map[byte][]byte{
+	LogAddrTypeTV:          {0},
+	LogAddrTypeRecord:      {1, 2, 9},
+	LogAddrTypeTuner:       {3, 6, 7, 10},
+	LogAddrTypePlayback:    {4, 8, 11},
+	LogAddrTypeAudioSystem: {5},
+}
+
+func newFakeDevice(physical uint16) *fakeDevice {
+	return &fakeDevice{physical: physical, taken: make(map[byte]bool), available: make(chan struct{}, 1)}
+}
+
+func (d *fakeDevice) Capabilities(caps *Caps) error {
+	copy(caps.Driver[:], "fake")
+	caps.AvailableLogAddrs = 1
+	return nil
+}
+
+func (d *fakeDevice) SetMode(mode uint32) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.mode = mode
+	return nil
+}
+
+func (d *fakeDevice) PhysicalAddress() (uint16, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.physical, nil
+}
+
+func (d *fakeDevice) LogicalAddresses(addrs *LogAddrs) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	*addrs = d.addrs
+	return nil
+}
+
+func (d *fakeDevice) SetLogicalAddresses(addrs *LogAddrs) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.busy {
+		return syscall.EBUSY
+	}
+	d.addrs = *addrs
+	d.addrs.LogAddr[0] = LogAddrInvalid
+	for _, candidate := range fakeCandidates[addrs.LogAddrType[0]] {
+		if !d.taken[candidate] {
+			d.addrs.LogAddr[0] = candidate
+			d.addrs.LogAddrMask = 1 << candidate
+			return nil
+		}
+	}
+	if addrs.Flags&LogAddrsAllowUnregFallback != 0 {
+		d.addrs.LogAddr[0] = 0xF
+	}
+	return nil
+}
+
+func (d *fakeDevice) Transmit(msg *Msg) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.err != nil {
+		return d.err
+	}
+	frame := msg.Frame()
+	d.sent = append(d.sent, frame)
+
+	msg.TxStatus = TxStatusOK
+	var reply []byte
+	if d.reply != nil {
+		msg.TxStatus, reply = d.reply(frame)
+	}
+	if msg.Reply == 0 || msg.TxStatus&TxStatusOK == 0 {
+		return nil
+	}
+
+	switch {
+	case reply == nil:
+		msg.RxStatus = RxStatusTimeout
+	case len(reply) >= 2 && reply[1] == 0x00:
+		msg.RxStatus = RxStatusOK | RxStatusFeatureAbort
+	default:
+		msg.RxStatus = RxStatusOK
+	}
+	msg.Len = uint32(copy(msg.Msg[:], reply))
+	return nil
+}
+
+// deliver - queue a received frame
+func (d *fakeDevice) deliver(frame []byte) {
+	msg := Msg{Len: uint32(len(frame)), RxStatus: RxStatusOK}
+	copy(msg.Msg[:], frame)
+
+	d.mu.Lock()
+	d.messages = append(d.messages, msg)
+	d.mu.Unlock()
+	d.signal()
+}
+
+// event - queue an event
+func (d *fakeDevice) event(ev Event) {
+	d.mu.Lock()
+	d.events = append(d.events, ev)
+	d.mu.Unlock()
+	d.signal()
+}
+
+func (d *fakeDevice) signal() {
+	select {
+	case d.available <- struct{}{}:
+	default:
+	}
+}
+
+func (d *fakeDevice) Receive(msg *Msg) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if len(d.messages) == 0 {
+		return syscall.EAGAIN
+	}
+	*msg = d.messages[0]
+	d.messages = d.messages[1:]
+	return nil
+}
+
+func (d *fakeDevice) DequeueEvent(event *Event) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if len(d.events) == 0 {
+		return syscall.EAGAIN
+	}
+	*event = d.events[0]
+	d.events = d.events[1:]
+	return nil
+}
+
+func (d *fakeDevice) Wait(timeout time.Duration) (bool, bool, error) {
+	d.mu.Lock()
+	message, event := len(d.messages) > 0, len(d.events) > 0
+	d.mu.Unlock()
+	if message || event {
+		return message, event, nil
+	}
+
+	select {
+	case <-d.available:
+	case <-time.After(timeout):
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return len(d.messages) > 0, len(d.events) > 0, nil
+}
+
+func (d *fakeDevice) Close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.closed = true
+	return nil
+}
+
+// frames - the frames transmitted so far
+func (d *fakeDevice) frames() [][]byte {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return append([][]byte(nil), d.sent...)
+}
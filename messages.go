@@ -0,0 +1,1267 @@
+package cec
+
+import (
+	"fmt"
+	"time"
+)
+
+// Messages without operands
+
+// ImageViewOn - <Image View On>, turn the TV on and show the source
+type ImageViewOn struct{}
+
+// TextViewOn - <Text View On>, like ImageViewOn but also removes menus
+type TextViewOn struct{}
+
+// Standby - <Standby>, switch the destination (or everyone) to standby
+type Standby struct{}
+
+// RequestActiveSource - <Request Active Source>
+type RequestActiveSource struct{}
+
+// GivePhysicalAddress - <Give Physical Address>
+type GivePhysicalAddress struct{}
+
+// GetMenuLanguage - <Get Menu Language>
+type GetMenuLanguage struct{}
+
+// GetCECVersion - <Get CEC Version>
+type GetCECVersion struct{}
+
+// GiveOSDName - <Give OSD Name>
+type GiveOSDName struct{}
+
+// GiveDeviceVendorID - <Give Device Vendor ID>
+type GiveDeviceVendorID struct{}
+
+// GiveDevicePowerStatus - <Give Device Power Status>
+type GiveDevicePowerStatus struct{}
+
+// GiveAudioStatus - <Give Audio Status>
+type GiveAudioStatus struct{}
+
+// GiveSystemAudioModeStatus - <Give System Audio Mode Status>
+type GiveSystemAudioModeStatus struct{}
+
+// GiveFeatures - <Give Features> (CEC 2.0)
+type GiveFeatures struct{}
+
+// UserControlReleased - <User Control Released>
+type UserControlReleased struct{}
+
+// VendorRemoteButtonUp - <Vendor Remote Button Up>
+type VendorRemoteButtonUp struct{}
+
+// TunerStepIncrement - <Tuner Step Increment>
+type TunerStepIncrement struct{}
+
+// TunerStepDecrement - <Tuner Step Decrement>
+type TunerStepDecrement struct{}
+
+// RecordOff - <Record Off>
+type RecordOff struct{}
+
+// RecordTVScreen - <Record TV Screen>
+type RecordTVScreen struct{}
+
+// InitiateARC - <Initiate ARC>
+type InitiateARC struct{}
+
+// ReportARCInitiated - <Report ARC Initiated>
+type ReportARCInitiated struct{}
+
+// ReportARCTerminated - <Report ARC Terminated>
+type ReportARCTerminated struct{}
+
+// RequestARCInitiation - <Request ARC Initiation>
+type RequestARCInitiation struct{}
+
+// RequestARCTermination - <Request ARC Termination>
+type RequestARCTermination struct{}
+
+// TerminateARC - <Terminate ARC>
+type TerminateARC struct{}
+
+// Abort - <Abort>, test message that is always answered with a feature abort
+type Abort struct{}
+
+func (ImageViewOn) Opcode() Opcode               { return OpImageViewOn }
+func (TextViewOn) Opcode() Opcode                { return OpTextViewOn }
+func (Standby) Opcode() Opcode                   { return OpStandby }
+func (RequestActiveSource) Opcode() Opcode       { return OpRequestActiveSource }
+func (GivePhysicalAddress) Opcode() Opcode       { return OpGivePhysicalAddress }
+func (GetMenuLanguage) Opcode() Opcode           { return OpGetMenuLanguage }
+func (GetCECVersion) Opcode() Opcode             { return OpGetCECVersion }
+func (GiveOSDName) Opcode() Opcode               { return OpGiveOSDName }
+func (GiveDeviceVendorID) Opcode() Opcode        { return OpGiveDeviceVendorID }
+func (GiveDevicePowerStatus) Opcode() Opcode     { return OpGiveDevicePowerStatus }
+func (GiveAudioStatus) Opcode() Opcode           { return OpGiveAudioStatus }
+func (GiveSystemAudioModeStatus) Opcode() Opcode { return OpGiveSystemAudioModeStatus }
+func (GiveFeatures) Opcode() Opcode              { return OpGiveFeatures }
+func (UserControlReleased) Opcode() Opcode       { return OpUserControlReleased }
+func (VendorRemoteButtonUp) Opcode() Opcode      { return OpVendorRemoteButtonUp }
+func (TunerStepIncrement) Opcode() Opcode        { return OpTunerStepIncrement }
+func (TunerStepDecrement) Opcode() Opcode        { return OpTunerStepDecrement }
+func (RecordOff) Opcode() Opcode                 { return OpRecordOff }
+func (RecordTVScreen) Opcode() Opcode            { return OpRecordTVScreen }
+func (InitiateARC) Opcode() Opcode               { return OpInitiateARC }
+func (ReportARCInitiated) Opcode() Opcode        { return OpReportARCInitiated }
+func (ReportARCTerminated) Opcode() Opcode       { return OpReportARCTerminated }
+func (RequestARCInitiation) Opcode() Opcode      { return OpRequestARCInitiation }
+func (RequestARCTermination) Opcode() Opcode     { return OpRequestARCTermination }
+func (TerminateARC) Opcode() Opcode              { return OpTerminateARC }
+func (Abort) Opcode() Opcode                     { return OpAbort }
+
+func (ImageViewOn) MarshalOperands() ([]byte, error)               { return nil, nil }
+func (TextViewOn) MarshalOperands() ([]byte, error)                { return nil, nil }
+func (Standby) MarshalOperands() ([]byte, error)                   { return nil, nil }
+func (RequestActiveSource) MarshalOperands() ([]byte, error)       { return nil, nil }
+func (GivePhysicalAddress) MarshalOperands() ([]byte, error)       { return nil, nil }
+func (GetMenuLanguage) MarshalOperands() ([]byte, error)           { return nil, nil }
+func (GetCECVersion) MarshalOperands() ([]byte, error)             { return nil, nil }
+func (GiveOSDName) MarshalOperands() ([]byte, error)               { return nil, nil }
+func (GiveDeviceVendorID) MarshalOperands() ([]byte, error)        { return nil, nil }
+func (GiveDevicePowerStatus) MarshalOperands() ([]byte, error)     { return nil, nil }
+func (GiveAudioStatus) MarshalOperands() ([]byte, error)           { return nil, nil }
+func (GiveSystemAudioModeStatus) MarshalOperands() ([]byte, error) { return nil, nil }
+func (GiveFeatures) MarshalOperands() ([]byte, error)              { return nil, nil }
+func (UserControlReleased) MarshalOperands() ([]byte, error)       { return nil, nil }
+func (VendorRemoteButtonUp) MarshalOperands() ([]byte, error)      { return nil, nil }
+func (TunerStepIncrement) MarshalOperands() ([]byte, error)        { return nil, nil }
+func (TunerStepDecrement) MarshalOperands() ([]byte, error)        { return nil, nil }
+func (RecordOff) MarshalOperands() ([]byte, error)                 { return nil, nil }
+func (RecordTVScreen) MarshalOperands() ([]byte, error)            { return nil, nil }
+func (InitiateARC) MarshalOperands() ([]byte, error)               { return nil, nil }
+func (ReportARCInitiated) MarshalOperands() ([]byte, error)        { return nil, nil }
+func (ReportARCTerminated) MarshalOperands() ([]byte, error)       { return nil, nil }
+func (RequestARCInitiation) MarshalOperands() ([]byte, error)      { return nil, nil }
+func (RequestARCTermination) MarshalOperands() ([]byte, error)     { return nil, nil }
+func (TerminateARC) MarshalOperands() ([]byte, error)              { return nil, nil }
+func (Abort) MarshalOperands() ([]byte, error)                     { return nil, nil }
+
+// General
+
+// FeatureAbort - <Feature Abort>, the destination does not support or
+// cannot handle the message with opcode Feature
+type FeatureAbort struct {
+	Feature Opcode
+	Reason  byte
+}
+
+func (FeatureAbort) Opcode() Opcode { return OpFeatureAbort }
+
+func (m FeatureAbort) MarshalOperands() ([]byte, error) {
+	return []byte{byte(m.Feature), m.Reason}, nil
+}
+
+// CECVersion - <CEC Version>
+type CECVersion struct {
+	Version byte
+}
+
+func (CECVersion) Opcode() Opcode { return OpCECVersion }
+
+func (m CECVersion) MarshalOperands() ([]byte, error) {
+	return []byte{m.Version}, nil
+}
+
+// SetMenuLanguage - <Set Menu Language>, an ISO 639-2 language code
+type SetMenuLanguage struct {
+	Language string
+}
+
+func (SetMenuLanguage) Opcode() Opcode { return OpSetMenuLanguage }
+
+func (m SetMenuLanguage) MarshalOperands() ([]byte, error) {
+	return asciiOperand(OpSetMenuLanguage, m.Language, 3, 3)
+}
+
+// ReportFeatures - <Report Features> (CEC 2.0). RCProfile and DeviceFeatures
+// hold the raw operand bytes, each with bit 7 set if another byte follows.
+type ReportFeatures struct {
+	Version        byte
+	DeviceTypes    byte
+	RCProfile      []byte
+	DeviceFeatures []byte
+}
+
+func (ReportFeatures) Opcode() Opcode { return OpReportFeatures }
+
+func (m ReportFeatures) MarshalOperands() ([]byte, error) {
+	if len(m.RCProfile) == 0 || len(m.DeviceFeatures) == 0 {
+		return nil, fmt.Errorf("%w: report features: RC profile and device features are required", ErrInvalidFrame)
+	}
+	b := []byte{m.Version, m.DeviceTypes}
+	b = append(b, m.RCProfile...)
+	return append(b, m.DeviceFeatures...), nil
+}
+
+// Routing control
+
+// ActiveSource - <Active Source>, the initiator is now the active source
+type ActiveSource struct {
+	Addr PhysicalAddress
+}
+
+func (ActiveSource) Opcode() Opcode { return OpActiveSource }
+
+func (m ActiveSource) MarshalOperands() ([]byte, error) {
+	return appendPhysicalAddress(nil, m.Addr), nil
+}
+
+// InactiveSource - <Inactive Source>, the initiator stopped being the active
+// source
+type InactiveSource struct {
+	Addr PhysicalAddress
+}
+
+func (InactiveSource) Opcode() Opcode { return OpInactiveSource }
+
+func (m InactiveSource) MarshalOperands() ([]byte, error) {
+	return appendPhysicalAddress(nil, m.Addr), nil
+}
+
+// RoutingChange - <Routing Change>, a switch changed its active input
+type RoutingChange struct {
+	From PhysicalAddress
+	To   PhysicalAddress
+}
+
+func (RoutingChange) Opcode() Opcode { return OpRoutingChange }
+
+func (m RoutingChange) MarshalOperands() ([]byte, error) {
+	return appendPhysicalAddress(appendPhysicalAddress(nil, m.From), m.To), nil
+}
+
+// RoutingInformation - <Routing Information>, the active route below a
+// switch
+type RoutingInformation struct {
+	Addr PhysicalAddress
+}
+
+func (RoutingInformation) Opcode() Opcode { return OpRoutingInformation }
+
+func (m RoutingInformation) MarshalOperands() ([]byte, error) {
+	return appendPhysicalAddress(nil, m.Addr), nil
+}
+
+// SetStreamPath - <Set Stream Path>, ask the device at Addr to become the
+// active source
+type SetStreamPath struct {
+	Addr PhysicalAddress
+}
+
+func (SetStreamPath) Opcode() Opcode { return OpSetStreamPath }
+
+func (m SetStreamPath) MarshalOperands() ([]byte, error) {
+	return appendPhysicalAddress(nil, m.Addr), nil
+}
+
+// ReportPhysicalAddress - <Report Physical Address>
+type ReportPhysicalAddress struct {
+	Addr       PhysicalAddress
+	DeviceType DeviceType
+}
+
+func (ReportPhysicalAddress) Opcode() Opcode { return OpReportPhysicalAddress }
+
+func (m ReportPhysicalAddress) MarshalOperands() ([]byte, error) {
+	if !m.DeviceType.IsValid() {
+		return nil, fmt.Errorf("%w: %s: invalid device type %d", ErrInvalidFrame, OpReportPhysicalAddress, m.DeviceType)
+	}
+	return append(appendPhysicalAddress(nil, m.Addr), byte(m.DeviceType)), nil
+}
+
+// OSD
+
+// SetOSDName - <Set OSD Name>
+type SetOSDName struct {
+	Name string
+}
+
+func (SetOSDName) Opcode() Opcode { return OpSetOSDName }
+
+func (m SetOSDName) MarshalOperands() ([]byte, error) {
+	return asciiOperand(OpSetOSDName, m.Name, 1, 14)
+}
+
+// SetOSDString - <Set OSD String>, a text for the TV to display
+type SetOSDString struct {
+	Control DisplayControl
+	Text    string
+}
+
+func (SetOSDString) Opcode() Opcode { return OpSetOSDString }
+
+func (m SetOSDString) MarshalOperands() ([]byte, error) {
+	if !m.Control.IsValid() {
+		return nil, fmt.Errorf("%w: %s: invalid display control 0x%02x", ErrInvalidFrame, OpSetOSDString, byte(m.Control))
+	}
+	text, err := asciiOperand(OpSetOSDString, m.Text, 1, osdStringSize)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte{byte(m.Control)}, text...), nil
+}
+
+// Power and menus
+
+// ReportPowerStatus - <Report Power Status>
+type ReportPowerStatus struct {
+	Status PowerStatus
+}
+
+func (ReportPowerStatus) Opcode() Opcode { return OpReportPowerStatus }
+
+func (m ReportPowerStatus) MarshalOperands() ([]byte, error) {
+	if m.Status > PowerStatusOnToStandby {
+		return nil, fmt.Errorf("%w: %s: invalid power status %d", ErrInvalidFrame, OpReportPowerStatus, m.Status)
+	}
+	return []byte{byte(m.Status)}, nil
+}
+
+// MenuRequest - <Menu Request>, activate, deactivate or query the menu
+type MenuRequest struct {
+	Request byte
+}
+
+func (MenuRequest) Opcode() Opcode { return OpMenuRequest }
+
+func (m MenuRequest) MarshalOperands() ([]byte, error) {
+	return []byte{m.Request}, nil
+}
+
+// MenuStatus - <Menu Status>
+type MenuStatus struct {
+	State byte
+}
+
+func (MenuStatus) Opcode() Opcode { return OpMenuStatus }
+
+func (m MenuStatus) MarshalOperands() ([]byte, error) {
+	return []byte{m.State}, nil
+}
+
+// Remote control passthrough
+
+// UserControlPressed - <User Control Pressed>. Some keys (play function,
+// select media, ...) carry additional operands.
+type UserControlPressed struct {
+	Key      UserControlCode
+	Operands []byte
+}
+
+func (UserControlPressed) Opcode() Opcode { return OpUserControlPressed }
+
+func (m UserControlPressed) MarshalOperands() ([]byte, error) {
+	return append([]byte{byte(m.Key)}, m.Operands...), nil
+}
+
+// Vendor specific
+
+// DeviceVendorID - <Device Vendor ID>
+type DeviceVendorID struct {
+	VendorID uint32
+}
+
+func (DeviceVendorID) Opcode() Opcode { return OpDeviceVendorID }
+
+func (m DeviceVendorID) MarshalOperands() ([]byte, error) {
+	return appendVendorID(nil, m.VendorID)
+}
+
+// VendorCommand - <Vendor Command>
+type VendorCommand struct {
+	Data []byte
+}
+
+func (VendorCommand) Opcode() Opcode { return OpVendorCommand }
+
+func (m VendorCommand) MarshalOperands() ([]byte, error) {
+	return bytesOperand(OpVendorCommand, m.Data, 1, 14)
+}
+
+// VendorCommandWithID - <Vendor Command With ID>
+type VendorCommandWithID struct {
+	VendorID uint32
+	Data     []byte
+}
+
+func (VendorCommandWithID) Opcode() Opcode { return OpVendorCommandWithID }
+
+func (m VendorCommandWithID) MarshalOperands() ([]byte, error) {
+	data, err := bytesOperand(OpVendorCommandWithID, m.Data, 1, 11)
+	if err != nil {
+		return nil, err
+	}
+	b, err := appendVendorID(nil, m.VendorID)
+	if err != nil {
+		return nil, err
+	}
+	return append(b, data...), nil
+}
+
+// VendorRemoteButtonDown - <Vendor Remote Button Down>
+type VendorRemoteButtonDown struct {
+	Code []byte
+}
+
+func (VendorRemoteButtonDown) Opcode() Opcode { return OpVendorRemoteButtonDown }
+
+func (m VendorRemoteButtonDown) MarshalOperands() ([]byte, error) {
+	return bytesOperand(OpVendorRemoteButtonDown, m.Code, 1, 14)
+}
+
+// Audio
+
+// ReportAudioStatus - <Report Audio Status>, mute flag (bit 7) and volume
+type ReportAudioStatus struct {
+	Status byte
+}
+
+func (ReportAudioStatus) Opcode() Opcode { return OpReportAudioStatus }
+
+func (m ReportAudioStatus) MarshalOperands() ([]byte, error) {
+	return []byte{m.Status}, nil
+}
+
+// SystemAudioModeRequest - <System Audio Mode Request>. With On the audio
+// system is asked to play the audio of the source at Addr, without it to
+// turn System Audio Mode off.
+type SystemAudioModeRequest struct {
+	On   bool
+	Addr PhysicalAddress
+}
+
+func (SystemAudioModeRequest) Opcode() Opcode { return OpSystemAudioModeRequest }
+
+func (m SystemAudioModeRequest) MarshalOperands() ([]byte, error) {
+	if !m.On {
+		return nil, nil
+	}
+	return appendPhysicalAddress(nil, m.Addr), nil
+}
+
+// SetSystemAudioMode - <Set System Audio Mode>
+type SetSystemAudioMode struct {
+	On bool
+}
+
+func (SetSystemAudioMode) Opcode() Opcode { return OpSetSystemAudioMode }
+
+func (m SetSystemAudioMode) MarshalOperands() ([]byte, error) {
+	return appendBool(nil, m.On), nil
+}
+
+// SystemAudioModeStatus - <System Audio Mode Status>
+type SystemAudioModeStatus struct {
+	On bool
+}
+
+func (SystemAudioModeStatus) Opcode() Opcode { return OpSystemAudioModeStatus }
+
+func (m SystemAudioModeStatus) MarshalOperands() ([]byte, error) {
+	return appendBool(nil, m.On), nil
+}
+
+// SetAudioRate - <Set Audio Rate>
+type SetAudioRate struct {
+	Rate byte
+}
+
+func (SetAudioRate) Opcode() Opcode { return OpSetAudioRate }
+
+func (m SetAudioRate) MarshalOperands() ([]byte, error) {
+	return []byte{m.Rate}, nil
+}
+
+// ReportShortAudioDescriptor - <Report Short Audio Descriptor>, up to four
+// 3 byte descriptors
+type ReportShortAudioDescriptor struct {
+	Descriptors [][3]byte
+}
+
+func (ReportShortAudioDescriptor) Opcode() Opcode { return OpReportShortAudioDescriptor }
+
+func (m ReportShortAudioDescriptor) MarshalOperands() ([]byte, error) {
+	if len(m.Descriptors) < 1 || len(m.Descriptors) > 4 {
+		return nil, fmt.Errorf("%w: report short audio descriptor: expected 1 to 4 descriptors", ErrInvalidFrame)
+	}
+	var b []byte
+	for _, d := range m.Descriptors {
+		b = append(b, d[:]...)
+	}
+	return b, nil
+}
+
+// RequestShortAudioDescriptor - <Request Short Audio Descriptor>, up to four
+// audio format IDs and codes
+type RequestShortAudioDescriptor struct {
+	Formats []byte
+}
+
+func (RequestShortAudioDescriptor) Opcode() Opcode { return OpRequestShortAudioDescriptor }
+
+func (m RequestShortAudioDescriptor) MarshalOperands() ([]byte, error) {
+	return bytesOperand(OpRequestShortAudioDescriptor, m.Formats, 1, 4)
+}
+
+// RequestCurrentLatency - <Request Current Latency> (CEC 2.0)
+type RequestCurrentLatency struct {
+	Addr PhysicalAddress
+}
+
+func (RequestCurrentLatency) Opcode() Opcode { return OpRequestCurrentLatency }
+
+func (m RequestCurrentLatency) MarshalOperands() ([]byte, error) {
+	return appendPhysicalAddress(nil, m.Addr), nil
+}
+
+// ReportCurrentLatency - <Report Current Latency> (CEC 2.0). AudioOutputDelay
+// is only sent when the flags say the audio output is compensated (3).
+type ReportCurrentLatency struct {
+	Addr             PhysicalAddress
+	VideoLatency     byte
+	Flags            byte
+	AudioOutputDelay byte
+}
+
+func (ReportCurrentLatency) Opcode() Opcode { return OpReportCurrentLatency }
+
+func (m ReportCurrentLatency) MarshalOperands() ([]byte, error) {
+	b := append(appendPhysicalAddress(nil, m.Addr), m.VideoLatency, m.Flags)
+	if m.Flags&0x3 == 0x3 {
+		b = append(b, m.AudioOutputDelay)
+	}
+	return b, nil
+}
+
+// Deck control
+
+// GiveDeckStatus - <Give Deck Status>
+type GiveDeckStatus struct {
+	Request StatusRequest
+}
+
+func (GiveDeckStatus) Opcode() Opcode { return OpGiveDeckStatus }
+
+func (m GiveDeckStatus) MarshalOperands() ([]byte, error) {
+	if !m.Request.IsValid() {
+		return nil, fmt.Errorf("%w: %s: invalid status request %d", ErrInvalidFrame, OpGiveDeckStatus, m.Request)
+	}
+	return []byte{byte(m.Request)}, nil
+}
+
+// DeckStatus - <Deck Status>
+type DeckStatus struct {
+	Info DeckInfo
+}
+
+func (DeckStatus) Opcode() Opcode { return OpDeckStatus }
+
+func (m DeckStatus) MarshalOperands() ([]byte, error) {
+	if !m.Info.IsValid() {
+		return nil, fmt.Errorf("%w: %s: invalid deck info %d", ErrInvalidFrame, OpDeckStatus, m.Info)
+	}
+	return []byte{byte(m.Info)}, nil
+}
+
+// DeckControl - <Deck Control>
+type DeckControl struct {
+	Mode DeckControlMode
+}
+
+func (DeckControl) Opcode() Opcode { return OpDeckControl }
+
+func (m DeckControl) MarshalOperands() ([]byte, error) {
+	if !m.Mode.IsValid() {
+		return nil, fmt.Errorf("%w: %s: invalid deck control mode %d", ErrInvalidFrame, OpDeckControl, m.Mode)
+	}
+	return []byte{byte(m.Mode)}, nil
+}
+
+// Play - <Play>
+type Play struct {
+	Mode PlayMode
+}
+
+func (Play) Opcode() Opcode { return OpPlay }
+
+func (m Play) MarshalOperands() ([]byte, error) {
+	if !m.Mode.IsValid() {
+		return nil, fmt.Errorf("%w: %s: invalid play mode %d", ErrInvalidFrame, OpPlay, m.Mode)
+	}
+	return []byte{byte(m.Mode)}, nil
+}
+
+// Tuner control
+
+// GiveTunerDeviceStatus - <Give Tuner Device Status>
+type GiveTunerDeviceStatus struct {
+	Request StatusRequest
+}
+
+func (GiveTunerDeviceStatus) Opcode() Opcode { return OpGiveTunerDeviceStatus }
+
+func (m GiveTunerDeviceStatus) MarshalOperands() ([]byte, error) {
+	if !m.Request.IsValid() {
+		return nil, fmt.Errorf("%w: %s: invalid status request %d", ErrInvalidFrame, OpGiveTunerDeviceStatus, m.Request)
+	}
+	return []byte{byte(m.Request)}, nil
+}
+
+// TunerDeviceStatus - <Tuner Device Status>, the service the tuner is
+// tuned to is in Digital or Analogue (the other one is nil)
+type TunerDeviceStatus struct {
+	Recording bool
+	Display   TunerDisplayInfo
+	Digital   *DigitalService
+	Analogue  *AnalogueService
+}
+
+func (TunerDeviceStatus) Opcode() Opcode { return OpTunerDeviceStatus }
+
+func (m TunerDeviceStatus) MarshalOperands() ([]byte, error) {
+	if !m.Display.IsValid() {
+		return nil, fmt.Errorf("%w: %s: invalid tuner display info %d", ErrInvalidFrame, OpTunerDeviceStatus, m.Display)
+	}
+	info := byte(m.Display)
+	if m.Recording {
+		info |= 0x80
+	}
+
+	switch {
+	case m.Digital != nil && m.Analogue == nil:
+		return appendDigitalService([]byte{info}, *m.Digital)
+	case m.Analogue != nil && m.Digital == nil:
+		return appendAnalogueService([]byte{info}, *m.Analogue)
+	}
+	return nil, fmt.Errorf("%w: %s: expected a digital or an analogue service", ErrInvalidFrame, OpTunerDeviceStatus)
+}
+
+// SelectAnalogueService - <Select Analogue Service>
+type SelectAnalogueService struct {
+	Service AnalogueService
+}
+
+func (SelectAnalogueService) Opcode() Opcode { return OpSelectAnalogueService }
+
+func (m SelectAnalogueService) MarshalOperands() ([]byte, error) {
+	return appendAnalogueService(nil, m.Service)
+}
+
+// SelectDigitalService - <Select Digital Service>
+type SelectDigitalService struct {
+	Service DigitalService
+}
+
+func (SelectDigitalService) Opcode() Opcode { return OpSelectDigitalService }
+
+func (m SelectDigitalService) MarshalOperands() ([]byte, error) {
+	return appendDigitalService(nil, m.Service)
+}
+
+// One touch record and timers
+
+// RecordOn - <Record On>
+type RecordOn struct {
+	Source RecordSource
+}
+
+func (RecordOn) Opcode() Opcode { return OpRecordOn }
+
+func (m RecordOn) MarshalOperands() ([]byte, error) {
+	return appendRecordSource(nil, m.Source)
+}
+
+// RecordStatus - <Record Status>
+type RecordStatus struct {
+	Status RecordStatusInfo
+}
+
+func (RecordStatus) Opcode() Opcode { return OpRecordStatus }
+
+func (m RecordStatus) MarshalOperands() ([]byte, error) {
+	if !m.Status.IsValid() {
+		return nil, fmt.Errorf("%w: %s: invalid record status %d", ErrInvalidFrame, OpRecordStatus, m.Status)
+	}
+	return []byte{byte(m.Status)}, nil
+}
+
+// SetAnalogueTimer - <Set Analogue Timer>
+type SetAnalogueTimer struct {
+	Timer   Timer
+	Service AnalogueService
+}
+
+func (SetAnalogueTimer) Opcode() Opcode { return OpSetAnalogueTimer }
+
+func (m SetAnalogueTimer) MarshalOperands() ([]byte, error) {
+	b, err := appendTimer(nil, m.Timer)
+	if err != nil {
+		return nil, err
+	}
+	return appendAnalogueService(b, m.Service)
+}
+
+// ClearAnalogueTimer - <Clear Analogue Timer>
+type ClearAnalogueTimer struct {
+	Timer   Timer
+	Service AnalogueService
+}
+
+func (ClearAnalogueTimer) Opcode() Opcode { return OpClearAnalogueTimer }
+
+func (m ClearAnalogueTimer) MarshalOperands() ([]byte, error) {
+	return SetAnalogueTimer(m).MarshalOperands()
+}
+
+// SetDigitalTimer - <Set Digital Timer>
+type SetDigitalTimer struct {
+	Timer   Timer
+	Service DigitalService
+}
+
+func (SetDigitalTimer) Opcode() Opcode { return OpSetDigitalTimer }
+
+func (m SetDigitalTimer) MarshalOperands() ([]byte, error) {
+	b, err := appendTimer(nil, m.Timer)
+	if err != nil {
+		return nil, err
+	}
+	return appendDigitalService(b, m.Service)
+}
+
+// ClearDigitalTimer - <Clear Digital Timer>
+type ClearDigitalTimer struct {
+	Timer   Timer
+	Service DigitalService
+}
+
+func (ClearDigitalTimer) Opcode() Opcode { return OpClearDigitalTimer }
+
+func (m ClearDigitalTimer) MarshalOperands() ([]byte, error) {
+	return SetDigitalTimer(m).MarshalOperands()
+}
+
+// SetExternalTimer - <Set External Timer>, Source is an external plug or
+// physical address
+type SetExternalTimer struct {
+	Timer  Timer
+	Source RecordSource
+}
+
+func (SetExternalTimer) Opcode() Opcode { return OpSetExternalTimer }
+
+func (m SetExternalTimer) MarshalOperands() ([]byte, error) {
+	b, err := appendTimer(nil, m.Timer)
+	if err != nil {
+		return nil, err
+	}
+	return appendExternalSource(b, m.Source)
+}
+
+// ClearExternalTimer - <Clear External Timer>
+type ClearExternalTimer struct {
+	Timer  Timer
+	Source RecordSource
+}
+
+func (ClearExternalTimer) Opcode() Opcode { return OpClearExternalTimer }
+
+func (m ClearExternalTimer) MarshalOperands() ([]byte, error) {
+	return SetExternalTimer(m).MarshalOperands()
+}
+
+// TimerStatus - <Timer Status>, Info is set for programmed timers, Reason
+// for timers that were not programmed
+type TimerStatus struct {
+	// Overlap - the timer overlaps with another one
+	Overlap    bool
+	Media      MediaInfo
+	Programmed bool
+	Info       ProgrammedInfo
+	Reason     NotProgrammedReason
+	// DurationAvailable - the recording time left on the media, reported
+	// with ProgrammedNotEnoughSpace, ProgrammedMaybeEnoughSpace and
+	// NotProgrammedDuplicate (0 if not reported)
+	DurationAvailable time.Duration
+}
+
+func (TimerStatus) Opcode() Opcode { return OpTimerStatus }
+
+func (m TimerStatus) MarshalOperands() ([]byte, error) {
+	if m.Media > MediaNotPresent {
+		return nil, fmt.Errorf("%w: %s: invalid media info %d", ErrInvalidFrame, OpTimerStatus, m.Media)
+	}
+	b := byte(m.Media) << 5
+	if m.Overlap {
+		b |= 0x80
+	}
+	if m.Programmed {
+		b |= 0x10 | byte(m.Info)&0xF
+	} else {
+		b |= byte(m.Reason) & 0xF
+	}
+	if !m.hasDuration() {
+		return []byte{b}, nil
+	}
+	return appendDuration([]byte{b}, m.DurationAvailable)
+}
+
+// hasDuration - whether the status includes the available duration
+func (m TimerStatus) hasDuration() bool {
+	if m.Programmed {
+		return m.Info == ProgrammedNotEnoughSpace || m.Info == ProgrammedMaybeEnoughSpace
+	}
+	return m.Reason == NotProgrammedDuplicate
+}
+
+// TimerClearedStatus - <Timer Cleared Status>
+type TimerClearedStatus struct {
+	Status TimerClearedInfo
+}
+
+func (TimerClearedStatus) Opcode() Opcode { return OpTimerClearedStatus }
+
+func (m TimerClearedStatus) MarshalOperands() ([]byte, error) {
+	if !m.Status.IsValid() {
+		return nil, fmt.Errorf("%w: %s: invalid timer cleared status %d", ErrInvalidFrame, OpTimerClearedStatus, m.Status)
+	}
+	return []byte{byte(m.Status)}, nil
+}
+
+// SetTimerProgramTitle - <Set Timer Program Title>
+type SetTimerProgramTitle struct {
+	Title string
+}
+
+func (SetTimerProgramTitle) Opcode() Opcode { return OpSetTimerProgramTitle }
+
+func (m SetTimerProgramTitle) MarshalOperands() ([]byte, error) {
+	return asciiOperand(OpSetTimerProgramTitle, m.Title, 1, 14)
+}
+
+// Capability discovery and control (HEAC)
+
+// CDCMessage - <CDC Message>, Addr is the physical address of the initiator
+type CDCMessage struct {
+	Addr      PhysicalAddress
+	CDCOpcode byte
+	Operands  []byte
+}
+
+func (CDCMessage) Opcode() Opcode { return OpCDCMessage }
+
+func (m CDCMessage) MarshalOperands() ([]byte, error) {
+	operands, err := bytesOperand(OpCDCMessage, m.Operands, 0, 11)
+	if err != nil {
+		return nil, err
+	}
+	return append(append(appendPhysicalAddress(nil, m.Addr), m.CDCOpcode), operands...), nil
+}
+
+// decoders - decode the operands of each known opcode
+var decoders = map[Opcode]func(b []byte) (Message, error){
+	OpImageViewOn:               func([]byte) (Message, error) { return ImageViewOn{}, nil },
+	OpTextViewOn:                func([]byte) (Message, error) { return TextViewOn{}, nil },
+	OpStandby:                   func([]byte) (Message, error) { return Standby{}, nil },
+	OpRequestActiveSource:       func([]byte) (Message, error) { return RequestActiveSource{}, nil },
+	OpGivePhysicalAddress:       func([]byte) (Message, error) { return GivePhysicalAddress{}, nil },
+	OpGetMenuLanguage:           func([]byte) (Message, error) { return GetMenuLanguage{}, nil },
+	OpGetCECVersion:             func([]byte) (Message, error) { return GetCECVersion{}, nil },
+	OpGiveOSDName:               func([]byte) (Message, error) { return GiveOSDName{}, nil },
+	OpGiveDeviceVendorID:        func([]byte) (Message, error) { return GiveDeviceVendorID{}, nil },
+	OpGiveDevicePowerStatus:     func([]byte) (Message, error) { return GiveDevicePowerStatus{}, nil },
+	OpGiveAudioStatus:           func([]byte) (Message, error) { return GiveAudioStatus{}, nil },
+	OpGiveSystemAudioModeStatus: func([]byte) (Message, error) { return GiveSystemAudioModeStatus{}, nil },
+	OpGiveFeatures:              func([]byte) (Message, error) { return GiveFeatures{}, nil },
+	OpUserControlReleased:       func([]byte) (Message, error) { return UserControlReleased{}, nil },
+	OpVendorRemoteButtonUp:      func([]byte) (Message, error) { return VendorRemoteButtonUp{}, nil },
+	OpTunerStepIncrement:        func([]byte) (Message, error) { return TunerStepIncrement{}, nil },
+	OpTunerStepDecrement:        func([]byte) (Message, error) { return TunerStepDecrement{}, nil },
+	OpRecordOff:                 func([]byte) (Message, error) { return RecordOff{}, nil },
+	OpRecordTVScreen:            func([]byte) (Message, error) { return RecordTVScreen{}, nil },
+	OpInitiateARC:               func([]byte) (Message, error) { return InitiateARC{}, nil },
+	OpReportARCInitiated:        func([]byte) (Message, error) { return ReportARCInitiated{}, nil },
+	OpReportARCTerminated:       func([]byte) (Message, error) { return ReportARCTerminated{}, nil },
+	OpRequestARCInitiation:      func([]byte) (Message, error) { return RequestARCInitiation{}, nil },
+	OpRequestARCTermination:     func([]byte) (Message, error) { return RequestARCTermination{}, nil },
+	OpTerminateARC:              func([]byte) (Message, error) { return TerminateARC{}, nil },
+	OpAbort:                     func([]byte) (Message, error) { return Abort{}, nil },
+
+	OpFeatureAbort: func(b []byte) (Message, error) {
+		if err := needOperands(OpFeatureAbort, b, 2); err != nil {
+			return nil, err
+		}
+		return FeatureAbort{Feature: Opcode(b[0]), Reason: b[1]}, nil
+	},
+	OpCECVersion: func(b []byte) (Message, error) {
+		if err := needOperands(OpCECVersion, b, 1); err != nil {
+			return nil, err
+		}
+		return CECVersion{Version: b[0]}, nil
+	},
+	OpSetMenuLanguage: func(b []byte) (Message, error) {
+		if err := needOperands(OpSetMenuLanguage, b, 3); err != nil {
+			return nil, err
+		}
+		return SetMenuLanguage{Language: string(b[:3])}, nil
+	},
+	OpReportFeatures: func(b []byte) (Message, error) {
+		if err := needOperands(OpReportFeatures, b, 4); err != nil {
+			return nil, err
+		}
+		m := ReportFeatures{Version: b[0], DeviceTypes: b[1]}
+		rest := b[2:]
+		m.RCProfile, rest = extendedOperand(rest)
+		m.DeviceFeatures, _ = extendedOperand(rest)
+		if len(m.DeviceFeatures) == 0 {
+			return nil, fmt.Errorf("%w: report features: missing device features", ErrInvalidFrame)
+		}
+		return m, nil
+	},
+
+	OpActiveSource: func(b []byte) (Message, error) {
+		if err := needOperands(OpActiveSource, b, 2); err != nil {
+			return nil, err
+		}
+		return ActiveSource{Addr: physicalAddressOperand(b)}, nil
+	},
+	OpInactiveSource: func(b []byte) (Message, error) {
+		if err := needOperands(OpInactiveSource, b, 2); err != nil {
+			return nil, err
+		}
+		return InactiveSource{Addr: physicalAddressOperand(b)}, nil
+	},
+	OpRoutingChange: func(b []byte) (Message, error) {
+		if err := needOperands(OpRoutingChange, b, 4); err != nil {
+			return nil, err
+		}
+		return RoutingChange{From: physicalAddressOperand(b), To: physicalAddressOperand(b[2:])}, nil
+	},
+	OpRoutingInformation: func(b []byte) (Message, error) {
+		if err := needOperands(OpRoutingInformation, b, 2); err != nil {
+			return nil, err
+		}
+		return RoutingInformation{Addr: physicalAddressOperand(b)}, nil
+	},
+	OpSetStreamPath: func(b []byte) (Message, error) {
+		if err := needOperands(OpSetStreamPath, b, 2); err != nil {
+			return nil, err
+		}
+		return SetStreamPath{Addr: physicalAddressOperand(b)}, nil
+	},
+	OpReportPhysicalAddress: func(b []byte) (Message, error) {
+		if err := needOperands(OpReportPhysicalAddress, b, 3); err != nil {
+			return nil, err
+		}
+		return ReportPhysicalAddress{Addr: physicalAddressOperand(b), DeviceType: DeviceType(b[2])}, nil
+	},
+
+	OpSetOSDName: func(b []byte) (Message, error) {
+		if err := needOperands(OpSetOSDName, b, 1); err != nil {
+			return nil, err
+		}
+		return SetOSDName{Name: string(b)}, nil
+	},
+	OpSetOSDString: func(b []byte) (Message, error) {
+		if err := needOperands(OpSetOSDString, b, 2); err != nil {
+			return nil, err
+		}
+		return SetOSDString{Control: DisplayControl(b[0]), Text: string(b[1:])}, nil
+	},
+
+	OpReportPowerStatus: func(b []byte) (Message, error) {
+		if err := needOperands(OpReportPowerStatus, b, 1); err != nil {
+			return nil, err
+		}
+		return ReportPowerStatus{Status: PowerStatus(b[0])}, nil
+	},
+	OpMenuRequest: func(b []byte) (Message, error) {
+		if err := needOperands(OpMenuRequest, b, 1); err != nil {
+			return nil, err
+		}
+		return MenuRequest{Request: b[0]}, nil
+	},
+	OpMenuStatus: func(b []byte) (Message, error) {
+		if err := needOperands(OpMenuStatus, b, 1); err != nil {
+			return nil, err
+		}
+		return MenuStatus{State: b[0]}, nil
+	},
+
+	OpUserControlPressed: func(b []byte) (Message, error) {
+		if err := needOperands(OpUserControlPressed, b, 1); err != nil {
+			return nil, err
+		}
+		m := UserControlPressed{Key: UserControlCode(b[0])}
+		if len(b) > 1 {
+			m.Operands = b[1:]
+		}
+		return m, nil
+	},
+
+	OpDeviceVendorID: func(b []byte) (Message, error) {
+		if err := needOperands(OpDeviceVendorID, b, 3); err != nil {
+			return nil, err
+		}
+		return DeviceVendorID{VendorID: vendorIDOperand(b)}, nil
+	},
+	OpVendorCommand: func(b []byte) (Message, error) {
+		if err := needOperands(OpVendorCommand, b, 1); err != nil {
+			return nil, err
+		}
+		return VendorCommand{Data: b}, nil
+	},
+	OpVendorCommandWithID: func(b []byte) (Message, error) {
+		if err := needOperands(OpVendorCommandWithID, b, 4); err != nil {
+			return nil, err
+		}
+		return VendorCommandWithID{VendorID: vendorIDOperand(b), Data: b[3:]}, nil
+	},
+	OpVendorRemoteButtonDown: func(b []byte) (Message, error) {
+		if err := needOperands(OpVendorRemoteButtonDown, b, 1); err != nil {
+			return nil, err
+		}
+		return VendorRemoteButtonDown{Code: b}, nil
+	},
+
+	OpReportAudioStatus: func(b []byte) (Message, error) {
+		if err := needOperands(OpReportAudioStatus, b, 1); err != nil {
+			return nil, err
+		}
+		return ReportAudioStatus{Status: b[0]}, nil
+	},
+	OpSystemAudioModeRequest: func(b []byte) (Message, error) {
+		if len(b) < 2 {
+			return SystemAudioModeRequest{}, nil
+		}
+		return SystemAudioModeRequest{On: true, Addr: physicalAddressOperand(b)}, nil
+	},
+	OpSetSystemAudioMode: func(b []byte) (Message, error) {
+		if err := needOperands(OpSetSystemAudioMode, b, 1); err != nil {
+			return nil, err
+		}
+		return SetSystemAudioMode{On: boolOperand(b[0])}, nil
+	},
+	OpSystemAudioModeStatus: func(b []byte) (Message, error) {
+		if err := needOperands(OpSystemAudioModeStatus, b, 1); err != nil {
+			return nil, err
+		}
+		return SystemAudioModeStatus{On: boolOperand(b[0])}, nil
+	},
+	OpSetAudioRate: func(b []byte) (Message, error) {
+		if err := needOperands(OpSetAudioRate, b, 1); err != nil {
+			return nil, err
+		}
+		return SetAudioRate{Rate: b[0]}, nil
+	},
+	OpReportShortAudioDescriptor: func(b []byte) (Message, error) {
+		if err := needOperands(OpReportShortAudioDescriptor, b, 3); err != nil {
+			return nil, err
+		}
+		var m ReportShortAudioDescriptor
+		for ; len(b) >= 3 && len(m.Descriptors) < 4; b = b[3:] {
+			m.Descriptors = append(m.Descriptors, [3]byte{b[0], b[1], b[2]})
+		}
+		return m, nil
+	},
+	OpRequestShortAudioDescriptor: func(b []byte) (Message, error) {
+		if err := needOperands(OpRequestShortAudioDescriptor, b, 1); err != nil {
+			return nil, err
+		}
+		if len(b) > 4 {
+			b = b[:4]
+		}
+		return RequestShortAudioDescriptor{Formats: b}, nil
+	},
+	OpRequestCurrentLatency: func(b []byte) (Message, error) {
+		if err := needOperands(OpRequestCurrentLatency, b, 2); err != nil {
+			return nil, err
+		}
+		return RequestCurrentLatency{Addr: physicalAddressOperand(b)}, nil
+	},
+	OpReportCurrentLatency: func(b []byte) (Message, error) {
+		if err := needOperands(OpReportCurrentLatency, b, 4); err != nil {
+			return nil, err
+		}
+		m := ReportCurrentLatency{Addr: physicalAddressOperand(b), VideoLatency: b[2], Flags: b[3]}
+		if m.Flags&0x3 == 0x3 {
+			if err := needOperands(OpReportCurrentLatency, b, 5); err != nil {
+				return nil, err
+			}
+			m.AudioOutputDelay = b[4]
+		}
+		return m, nil
+	},
+
+	OpGiveDeckStatus: func(b []byte) (Message, error) {
+		if err := needOperands(OpGiveDeckStatus, b, 1); err != nil {
+			return nil, err
+		}
+		return GiveDeckStatus{Request: StatusRequest(b[0])}, nil
+	},
+	OpDeckStatus: func(b []byte) (Message, error) {
+		if err := needOperands(OpDeckStatus, b, 1); err != nil {
+			return nil, err
+		}
+		return DeckStatus{Info: DeckInfo(b[0])}, nil
+	},
+	OpDeckControl: func(b []byte) (Message, error) {
+		if err := needOperands(OpDeckControl, b, 1); err != nil {
+			return nil, err
+		}
+		return DeckControl{Mode: DeckControlMode(b[0])}, nil
+	},
+	OpPlay: func(b []byte) (Message, error) {
+		if err := needOperands(OpPlay, b, 1); err != nil {
+			return nil, err
+		}
+		return Play{Mode: PlayMode(b[0])}, nil
+	},
+
+	OpGiveTunerDeviceStatus: func(b []byte) (Message, error) {
+		if err := needOperands(OpGiveTunerDeviceStatus, b, 1); err != nil {
+			return nil, err
+		}
+		return GiveTunerDeviceStatus{Request: StatusRequest(b[0])}, nil
+	},
+	OpTunerDeviceStatus: func(b []byte) (Message, error) {
+		if err := needOperands(OpTunerDeviceStatus, b, 5); err != nil {
+			return nil, err
+		}
+		m := TunerDeviceStatus{Recording: b[0]&0x80 != 0, Display: TunerDisplayInfo(b[0] & 0x7F)}
+		if len(b) >= 8 {
+			service := digitalServiceOperand(b[1:])
+			m.Digital = &service
+		} else {
+			service := analogueServiceOperand(b[1:])
+			m.Analogue = &service
+		}
+		return m, nil
+	},
+	OpSelectAnalogueService: func(b []byte) (Message, error) {
+		if err := needOperands(OpSelectAnalogueService, b, 4); err != nil {
+			return nil, err
+		}
+		return SelectAnalogueService{Service: analogueServiceOperand(b)}, nil
+	},
+	OpSelectDigitalService: func(b []byte) (Message, error) {
+		if err := needOperands(OpSelectDigitalService, b, 7); err != nil {
+			return nil, err
+		}
+		return SelectDigitalService{Service: digitalServiceOperand(b)}, nil
+	},
+
+	OpRecordOn: func(b []byte) (Message, error) {
+		if err := needOperands(OpRecordOn, b, 1); err != nil {
+			return nil, err
+		}
+		source, err := recordSourceOperand(OpRecordOn, b)
+		if err != nil {
+			return nil, err
+		}
+		return RecordOn{Source: source}, nil
+	},
+	OpRecordStatus: func(b []byte) (Message, error) {
+		if err := needOperands(OpRecordStatus, b, 1); err != nil {
+			return nil, err
+		}
+		return RecordStatus{Status: RecordStatusInfo(b[0])}, nil
+	},
+	OpSetAnalogueTimer: func(b []byte) (Message, error) {
+		if err := needOperands(OpSetAnalogueTimer, b, 11); err != nil {
+			return nil, err
+		}
+		return SetAnalogueTimer{Timer: timerOperand(b), Service: analogueServiceOperand(b[7:])}, nil
+	},
+	OpClearAnalogueTimer: func(b []byte) (Message, error) {
+		if err := needOperands(OpClearAnalogueTimer, b, 11); err != nil {
+			return nil, err
+		}
+		return ClearAnalogueTimer{Timer: timerOperand(b), Service: analogueServiceOperand(b[7:])}, nil
+	},
+	OpSetDigitalTimer: func(b []byte) (Message, error) {
+		if err := needOperands(OpSetDigitalTimer, b, 14); err != nil {
+			return nil, err
+		}
+		return SetDigitalTimer{Timer: timerOperand(b), Service: digitalServiceOperand(b[7:])}, nil
+	},
+	OpClearDigitalTimer: func(b []byte) (Message, error) {
+		if err := needOperands(OpClearDigitalTimer, b, 14); err != nil {
+			return nil, err
+		}
+		return ClearDigitalTimer{Timer: timerOperand(b), Service: digitalServiceOperand(b[7:])}, nil
+	},
+	OpSetExternalTimer: func(b []byte) (Message, error) {
+		if err := needOperands(OpSetExternalTimer, b, 9); err != nil {
+			return nil, err
+		}
+		source, err := externalSourceOperand(OpSetExternalTimer, b[7:])
+		if err != nil {
+			return nil, err
+		}
+		return SetExternalTimer{Timer: timerOperand(b), Source: source}, nil
+	},
+	OpClearExternalTimer: func(b []byte) (Message, error) {
+		if err := needOperands(OpClearExternalTimer, b, 9); err != nil {
+			return nil, err
+		}
+		source, err := externalSourceOperand(OpClearExternalTimer, b[7:])
+		if err != nil {
+			return nil, err
+		}
+		return ClearExternalTimer{Timer: timerOperand(b), Source: source}, nil
+	},
+	OpTimerStatus: func(b []byte) (Message, error) {
+		if err := needOperands(OpTimerStatus, b, 1); err != nil {
+			return nil, err
+		}
+		m := TimerStatus{Overlap: b[0]&0x80 != 0, Media: MediaInfo(b[0] >> 5 & 0x3),
+			Programmed: b[0]&0x10 != 0}
+		if m.Programmed {
+			m.Info = ProgrammedInfo(b[0] & 0xF)
+		} else {
+			m.Reason = NotProgrammedReason(b[0] & 0xF)
+		}
+		if m.hasDuration() && len(b) >= 3 {
+			m.DurationAvailable = durationOperand(b[1:])
+		}
+		return m, nil
+	},
+	OpTimerClearedStatus: func(b []byte) (Message, error) {
+		if err := needOperands(OpTimerClearedStatus, b, 1); err != nil {
+			return nil, err
+		}
+		return TimerClearedStatus{Status: TimerClearedInfo(b[0])}, nil
+	},
+	OpSetTimerProgramTitle: func(b []byte) (Message, error) {
+		if err := needOperands(OpSetTimerProgramTitle, b, 1); err != nil {
+			return nil, err
+		}
+		return SetTimerProgramTitle{Title: string(b)}, nil
+	},
+
+	OpCDCMessage: func(b []byte) (Message, error) {
+		if err := needOperands(OpCDCMessage, b, 3); err != nil {
+			return nil, err
+		}
+		m := CDCMessage{Addr: physicalAddressOperand(b), CDCOpcode: b[2]}
+		if len(b) > 3 {
+			m.Operands = b[3:]
+		}
+		return m, nil
+	},
+}
+
+// extendedOperand - split off an operand whose bytes have bit 7 set while
+// another byte follows
+func extendedOperand(b []byte) (operand, rest []byte) {
+	for i, v := range b {
+		if v&0x80 == 0 {
+			return b[:i+1], b[i+1:]
+		}
+	}
+	return b, nil
+}
@@ -0,0 +1,49 @@
+package cec
+
+// audio status byte masks and limits
+const (
+	audioMuteStatusMask   = 0x80
+	audioVolumeStatusMask = 0x7F
+	audioVolumeMax        = 0x64
+)
+
+var logicalAddressNames = []string{"TV", "Recorder 1", "Recorder 2", "Tuner 1",
+	"Playback 1", "Audio", "Tuner 2", "Tuner 3",
+	"Playback 2", "Recorder 3", "Tuner 4", "Playback 3",
+	"Reserved 1", "Reserved 2", "Free use", "Broadcast"}
+
+var vendorNames = map[uint64]string{0x000039: "Toshiba", 0x0000F0: "Samsung",
+	0x0005CD: "Denon", 0x000678: "Marantz", 0x000982: "Loewe", 0x0009B0: "Onkyo",
+	0x000CB8: "Medion", 0x000CE7: "Toshiba", 0x0010FA: "Apple",
+	0x001582: "Pulse Eight", 0x001950: "Harman/Kardon", 0x001A11: "Google",
+	0x0020C7: "Akai", 0x002467: "AOC", 0x008045: "Panasonic", 0x00903E: "Philips",
+	0x009053: "Daewoo", 0x00A0DE: "Yamaha", 0x00D0D5: "Grundig",
+	0x00E036: "Pioneer", 0x00E091: "LG", 0x08001F: "Sharp", 0x080046: "Sony",
+	0x18C086: "Broadcom", 0x534850: "Sharp", 0x6B746D: "Vizio", 0x8065E9: "Benq",
+	0x9C645E: "Harman/Kardon"}
+
+// GetVendorString - Get vendor string by ID
+func GetVendorString(id uint64) string {
+	if name, ok := vendorNames[id]; ok {
+		return name
+	}
+	return "Unknown"
+}
+
+// GetOpcodeString - Get opcode string by hex
+func GetOpcodeString(opcode int) string {
+	return Opcode(opcode).String()
+}
+
+// GetUserControlKeyString - Get user control key string by int
+func GetUserControlKeyString(key int) string {
+	return UserControlCode(key).String()
+}
+
+// GetLogicalNameByAddress - get logical name by address
+func GetLogicalNameByAddress(addr int) string {
+	if addr < 0 || addr >= len(logicalAddressNames) {
+		return "Unknown"
+	}
+	return logicalAddressNames[addr]
+}
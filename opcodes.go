@@ -0,0 +1,174 @@
+package cec
+
+import (
+	"fmt"
+)
+
+// Opcode - a CEC opcode
+type Opcode byte
+
+// opcodes of CEC 1.4 and 2.0
+const (
+	OpFeatureAbort                Opcode = 0x00
+	OpImageViewOn                 Opcode = 0x04
+	OpTunerStepIncrement          Opcode = 0x05
+	OpTunerStepDecrement          Opcode = 0x06
+	OpTunerDeviceStatus           Opcode = 0x07
+	OpGiveTunerDeviceStatus       Opcode = 0x08
+	OpRecordOn                    Opcode = 0x09
+	OpRecordStatus                Opcode = 0x0A
+	OpRecordOff                   Opcode = 0x0B
+	OpTextViewOn                  Opcode = 0x0D
+	OpRecordTVScreen              Opcode = 0x0F
+	OpGiveDeckStatus              Opcode = 0x1A
+	OpDeckStatus                  Opcode = 0x1B
+	OpSetMenuLanguage             Opcode = 0x32
+	OpClearAnalogueTimer          Opcode = 0x33
+	OpSetAnalogueTimer            Opcode = 0x34
+	OpTimerStatus                 Opcode = 0x35
+	OpStandby                     Opcode = 0x36
+	OpPlay                        Opcode = 0x41
+	OpDeckControl                 Opcode = 0x42
+	OpTimerClearedStatus          Opcode = 0x43
+	OpUserControlPressed          Opcode = 0x44
+	OpUserControlReleased         Opcode = 0x45
+	OpGiveOSDName                 Opcode = 0x46
+	OpSetOSDName                  Opcode = 0x47
+	OpSetOSDString                Opcode = 0x64
+	OpSetTimerProgramTitle        Opcode = 0x67
+	OpSystemAudioModeRequest      Opcode = 0x70
+	OpGiveAudioStatus             Opcode = 0x71
+	OpSetSystemAudioMode          Opcode = 0x72
+	OpReportAudioStatus           Opcode = 0x7A
+	OpGiveSystemAudioModeStatus   Opcode = 0x7D
+	OpSystemAudioModeStatus       Opcode = 0x7E
+	OpRoutingChange               Opcode = 0x80
+	OpRoutingInformation          Opcode = 0x81
+	OpActiveSource                Opcode = 0x82
+	OpGivePhysicalAddress         Opcode = 0x83
+	OpReportPhysicalAddress       Opcode = 0x84
+	OpRequestActiveSource         Opcode = 0x85
+	OpSetStreamPath               Opcode = 0x86
+	OpDeviceVendorID              Opcode = 0x87
+	OpVendorCommand               Opcode = 0x89
+	OpVendorRemoteButtonDown      Opcode = 0x8A
+	OpVendorRemoteButtonUp        Opcode = 0x8B
+	OpGiveDeviceVendorID          Opcode = 0x8C
+	OpMenuRequest                 Opcode = 0x8D
+	OpMenuStatus                  Opcode = 0x8E
+	OpGiveDevicePowerStatus       Opcode = 0x8F
+	OpReportPowerStatus           Opcode = 0x90
+	OpGetMenuLanguage             Opcode = 0x91
+	OpSelectAnalogueService       Opcode = 0x92
+	OpSelectDigitalService        Opcode = 0x93
+	OpSetDigitalTimer             Opcode = 0x97
+	OpClearDigitalTimer           Opcode = 0x99
+	OpSetAudioRate                Opcode = 0x9A
+	OpInactiveSource              Opcode = 0x9D
+	OpCECVersion                  Opcode = 0x9E
+	OpGetCECVersion               Opcode = 0x9F
+	OpVendorCommandWithID         Opcode = 0xA0
+	OpClearExternalTimer          Opcode = 0xA1
+	OpSetExternalTimer            Opcode = 0xA2
+	OpReportShortAudioDescriptor  Opcode = 0xA3
+	OpRequestShortAudioDescriptor Opcode = 0xA4
+	OpGiveFeatures                Opcode = 0xA5
+	OpReportFeatures              Opcode = 0xA6
+	OpRequestCurrentLatency       Opcode = 0xA7
+	OpReportCurrentLatency        Opcode = 0xA8
+	OpInitiateARC                 Opcode = 0xC0
+	OpReportARCInitiated          Opcode = 0xC1
+	OpReportARCTerminated         Opcode = 0xC2
+	OpRequestARCInitiation        Opcode = 0xC3
+	OpRequestARCTermination       Opcode = 0xC4
+	OpTerminateARC                Opcode = 0xC5
+	OpCDCMessage                  Opcode = 0xF8
+	OpAbort                       Opcode = 0xFF
+)
+
+var opcodeNames = map[Opcode]string{OpActiveSource: "active source",
+	OpImageViewOn: "image view on", OpTextViewOn: "text view on",
+	OpInactiveSource: "inactive source", OpRequestActiveSource: "request active source",
+	OpRoutingChange: "routing change", OpRoutingInformation: "routing information",
+	OpSetStreamPath: "set stream path", OpStandby: "standby",
+	OpRecordOff: "record off", OpRecordOn: "record on",
+	OpRecordStatus: "record status", OpRecordTVScreen: "record TV screen",
+	OpClearAnalogueTimer: "clear analogue timer",
+	OpClearDigitalTimer:  "clear digital timer",
+	OpClearExternalTimer: "clear external timer",
+	OpSetAnalogueTimer:   "set analogue timer", OpSetDigitalTimer: "set digital timer",
+	OpSetExternalTimer: "set external timer", OpSetTimerProgramTitle: "set timer program title",
+	OpTimerClearedStatus: "timer cleared status", OpTimerStatus: "timer status",
+	OpCECVersion: "CEC version", OpGetCECVersion: "get CEC version",
+	OpGivePhysicalAddress: "give physical address", OpGetMenuLanguage: "get menu language",
+	OpReportPhysicalAddress: "report physical address", OpSetMenuLanguage: "set menu language",
+	OpDeckControl: "deck control", OpDeckStatus: "deck status",
+	OpGiveDeckStatus: "give deck status", OpPlay: "play",
+	OpGiveTunerDeviceStatus: "give tuner status",
+	OpSelectAnalogueService: "select analogue service",
+	OpSelectDigitalService:  "select digital service",
+	OpTunerDeviceStatus:     "tuner device status",
+	OpTunerStepDecrement:    "tuner step decrement",
+	OpTunerStepIncrement:    "tuner step increment",
+	OpDeviceVendorID:        "device vendor id", OpGiveDeviceVendorID: "give device vendor id",
+	OpVendorCommand: "vendor command", OpVendorCommandWithID: "vendor command with id",
+	OpVendorRemoteButtonDown: "vendor remote button down",
+	OpVendorRemoteButtonUp:   "vendor remote button up", OpSetOSDString: "set OSD string",
+	OpGiveOSDName: "give OSD name", OpSetOSDName: "set OSD name",
+	OpMenuRequest: "menu request", OpMenuStatus: "menu status",
+	OpUserControlPressed: "user control pressed", OpUserControlReleased: "user control release",
+	OpGiveDevicePowerStatus: "give device power status",
+	OpReportPowerStatus:     "report device power status", OpFeatureAbort: "feature abort",
+	OpAbort: "abort", OpGiveAudioStatus: "give audio status",
+	OpGiveSystemAudioModeStatus: "give audio mode status",
+	OpReportAudioStatus:         "report audio status", OpSetSystemAudioMode: "set system audio mode",
+	OpSystemAudioModeRequest: "system audio mode request",
+	OpSystemAudioModeStatus:  "system audio mode status", OpSetAudioRate: "set audio rate",
+	OpReportShortAudioDescriptor:  "report short audio descriptor",
+	OpRequestShortAudioDescriptor: "request short audio descriptor",
+	OpGiveFeatures:                "give features", OpReportFeatures: "report features",
+	OpRequestCurrentLatency: "request current latency",
+	OpReportCurrentLatency:  "report current latency",
+	OpInitiateARC:           "start ARC", OpReportARCInitiated: "report ARC started",
+	OpReportARCTerminated: "report ARC ended", OpRequestARCInitiation: "request ARC start",
+	OpRequestARCTermination: "request ARC end", OpTerminateARC: "end ARC",
+	OpCDCMessage: "CDC"}
+
+func (o Opcode) String() string {
+	if name, ok := opcodeNames[o]; ok {
+		return name
+	}
+	return "Unknown"
+}
+
+// IsValid - whether the opcode is defined by CEC
+func (o Opcode) IsValid() bool {
+	_, ok := opcodeNames[o]
+	return ok
+}
+
+// MarshalText - the name of the opcode ("report physical address"),
+// unknown opcodes as hex-code ("0x50")
+func (o Opcode) MarshalText() ([]byte, error) {
+	if !o.IsValid() {
+		return []byte(fmt.Sprintf("0x%02x", byte(o))), nil
+	}
+	return []byte(o.String()), nil
+}
+
+// UnmarshalText - accepts the names of String (without regard to case
+// and separators) or numbers
+func (o *Opcode) UnmarshalText(text []byte) error {
+	name := normalizeName(string(text))
+	for opcode, n := range opcodeNames {
+		if normalizeName(n) == name {
+			*o = opcode
+			return nil
+		}
+	}
+	if n, ok := parseNumber(string(text), 0xFF); ok {
+		*o = Opcode(n)
+		return nil
+	}
+	return fmt.Errorf("cec: unknown opcode %q", text)
+}
@@ -0,0 +1,56 @@
+package cec
+
+import (
+	"time"
+)
+
+// Option - a setting for Open and OpenBackend
+type Option func(*options)
+
+type options struct {
+	eventBufferSize int
+	overflowPolicy  OverflowPolicy
+	deviceTTL       time.Duration
+	pollInterval    time.Duration
+}
+
+func defaultOptions() options {
+	return options{
+		eventBufferSize: DefaultEventBufferSize,
+		overflowPolicy:  DropOldest,
+		deviceTTL:       DefaultDeviceTTL,
+	}
+}
+
+// WithEventBuffer - the number of events buffered for a slow consumer
+// before the overflow policy applies
+func WithEventBuffer(size int) Option {
+	return func(o *options) {
+		o.eventBufferSize = size
+	}
+}
+
+// WithOverflowPolicy - what to do with events when the buffer is full
+// (DropOldest by default)
+func WithOverflowPolicy(policy OverflowPolicy) Option {
+	return func(o *options) {
+		o.overflowPolicy = policy
+	}
+}
+
+// WithDeviceTTL - how long the cached state of a device is used before it
+// is queried again (DefaultDeviceTTL by default, 0 queries every time)
+func WithDeviceTTL(ttl time.Duration) Option {
+	return func(o *options) {
+		o.deviceTTL = ttl
+	}
+}
+
+// WithDevicePolling - poll every logical address at the given interval
+// to notice devices coming and going (DeviceAdded, DeviceRemoved) and to
+// refresh stale device state (off by default)
+func WithDevicePolling(interval time.Duration) Option {
+	return func(o *options) {
+		o.pollInterval = interval
+	}
+}
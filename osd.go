@@ -0,0 +1,114 @@
+package cec
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DisplayControl - how long the TV shows a Set OSD String
+type DisplayControl byte
+
+// display controls as used on the bus
+const (
+	DisplayDefaultTime   DisplayControl = 0x00
+	DisplayUntilCleared  DisplayControl = 0x40
+	DisplayClearPrevious DisplayControl = 0x80
+)
+
+var displayControlNames = map[DisplayControl]string{DisplayDefaultTime: "default time",
+	DisplayUntilCleared: "until cleared", DisplayClearPrevious: "clear previous"}
+
+// IsValid - whether the display control is defined by the standard
+func (d DisplayControl) IsValid() bool {
+	_, ok := displayControlNames[d]
+	return ok
+}
+
+func (d DisplayControl) String() string {
+	if name, ok := displayControlNames[d]; ok {
+		return name
+	}
+	return "Unknown"
+}
+
+// the longest text of one Set OSD String, and how long each part of a
+// longer text is shown before the next one is sent
+const (
+	osdStringSize     = 13
+	osdStringInterval = 3 * time.Second
+)
+
+// SetOSDString - show a text (printable ASCII) on the TV. Texts longer than
+// 13 characters are split at spaces and sent one part after the other,
+// each shown for a few seconds, so SetOSDString returns after the last part
+// was sent. With DisplayClearPrevious the text is ignored and the shown
+// text is removed.
+func (c *Connection) SetOSDString(ctx context.Context, address LogicalAddress, text string, control DisplayControl) error {
+	if !control.IsValid() {
+		return fmt.Errorf("cec: invalid display control 0x%02x", byte(control))
+	}
+	if control == DisplayClearPrevious {
+		// the string is required, but not shown
+		return c.SendContext(ctx, address, SetOSDString{Control: control, Text: " "})
+	}
+	for _, r := range text {
+		if r < 0x20 || r > 0x7E {
+			return fmt.Errorf("cec: invalid character %q in OSD string", r)
+		}
+	}
+	parts := splitOSDString(text)
+	if len(parts) == 0 {
+		return fmt.Errorf("cec: empty OSD string")
+	}
+
+	for i, part := range parts {
+		if i > 0 {
+			select {
+			case <-time.After(osdStringInterval):
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-c.done:
+				return fmt.Errorf("%w: connection destroyed", ErrAdapterLost)
+			}
+		}
+		if err := c.SendContext(ctx, address, SetOSDString{Control: control, Text: part}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// splitOSDString - split a text into parts of at most 13 characters, at
+// spaces if possible
+func splitOSDString(text string) []string {
+	if len(text) <= osdStringSize && strings.TrimSpace(text) != "" {
+		return []string{text}
+	}
+	var parts []string
+	part := ""
+	for _, word := range strings.Fields(text) {
+		for len(word) > osdStringSize {
+			if part != "" {
+				parts = append(parts, part)
+				part = ""
+			}
+			parts = append(parts, word[:osdStringSize])
+			word = word[osdStringSize:]
+		}
+		switch {
+		case part == "":
+			part = word
+		case len(part)+1+len(word) <= osdStringSize:
+			part += " " + word
+		default:
+			parts = append(parts, part)
+			part = word
+		}
+	}
+	if part != "" {
+		parts = append(parts, part)
+	}
+	return parts
+}
@@ -0,0 +1,135 @@
+package cec
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// PhysicalAddress - the position of a device in the HDMI tree, one nibble
+// per level ("1.2.0.0" is port 2 of the device on port 1 of the TV)
+type PhysicalAddress uint16
+
+const (
+	// RootPhysicalAddress - the address of the TV (0.0.0.0)
+	RootPhysicalAddress PhysicalAddress = 0x0000
+	// InvalidPhysicalAddress - reported by devices that have no address
+	// (f.f.f.f)
+	InvalidPhysicalAddress PhysicalAddress = 0xFFFF
+)
+
+// ParsePhysicalAddress - parse the dotted form ("1.2.0.0")
+func ParsePhysicalAddress(s string) (PhysicalAddress, error) {
+	parts := strings.Split(s, ".")
+	if len(parts) != 4 {
+		return InvalidPhysicalAddress, fmt.Errorf("cec: invalid physical address %q", s)
+	}
+
+	var a PhysicalAddress
+	for _, part := range parts {
+		n, err := strconv.ParseUint(part, 16, 4)
+		if err != nil {
+			return InvalidPhysicalAddress, fmt.Errorf("cec: invalid physical address %q", s)
+		}
+		a = a<<4 | PhysicalAddress(n)
+	}
+	return a, nil
+}
+
+func (a PhysicalAddress) String() string {
+	return fmt.Sprintf("%x.%x.%x.%x", a.nibble(0), a.nibble(1), a.nibble(2), a.nibble(3))
+}
+
+// nibble - the port at the given level (0-3)
+func (a PhysicalAddress) nibble(level int) int {
+	return int(a>>(12-4*uint(level))) & 0xF
+}
+
+// IsValid - not f.f.f.f and no port after a zero (like 1.0.2.0)
+func (a PhysicalAddress) IsValid() bool {
+	if a == InvalidPhysicalAddress {
+		return false
+	}
+	end := false
+	for level := 0; level < 4; level++ {
+		if a.nibble(level) == 0 {
+			end = true
+		} else if end {
+			return false
+		}
+	}
+	return true
+}
+
+// Depth - the number of levels below the TV (0 for the TV, 1 for a device
+// connected to the TV directly, ...)
+func (a PhysicalAddress) Depth() int {
+	depth := 0
+	for depth < 4 && a.nibble(depth) != 0 {
+		depth++
+	}
+	return depth
+}
+
+// Port - the port of the parent the device is connected to, 0 for the TV
+func (a PhysicalAddress) Port() int {
+	depth := a.Depth()
+	if depth == 0 {
+		return 0
+	}
+	return a.nibble(depth - 1)
+}
+
+// Parent - the address of the device this one is connected to,
+// InvalidPhysicalAddress for the TV
+func (a PhysicalAddress) Parent() PhysicalAddress {
+	depth := a.Depth()
+	if depth == 0 || !a.IsValid() {
+		return InvalidPhysicalAddress
+	}
+	return a &^ (0xF << (16 - 4*uint(depth)))
+}
+
+// Child - the address of the device on the given port (1-15)
+func (a PhysicalAddress) Child(port int) (PhysicalAddress, error) {
+	if !a.IsValid() {
+		return InvalidPhysicalAddress, fmt.Errorf("cec: invalid physical address %s", a)
+	}
+	if port < 1 || port > 0xF {
+		return InvalidPhysicalAddress, fmt.Errorf("cec: invalid port %d", port)
+	}
+	depth := a.Depth()
+	if depth == 4 {
+		return InvalidPhysicalAddress, fmt.Errorf("cec: %s has no ports", a)
+	}
+	return a | PhysicalAddress(port)<<(12-4*uint(depth)), nil
+}
+
+// IsChildOf - whether the device is connected to parent, directly or
+// through other devices
+func (a PhysicalAddress) IsChildOf(parent PhysicalAddress) bool {
+	if !a.IsValid() || !parent.IsValid() {
+		return false
+	}
+	for p := a.Parent(); p != InvalidPhysicalAddress; p = p.Parent() {
+		if p == parent {
+			return true
+		}
+	}
+	return false
+}
+
+// MarshalText - the dotted form ("1.2.0.0")
+func (a PhysicalAddress) MarshalText() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+// UnmarshalText - parse the dotted form
+func (a *PhysicalAddress) UnmarshalText(text []byte) error {
+	address, err := ParsePhysicalAddress(string(text))
+	if err != nil {
+		return err
+	}
+	*a = address
+	return nil
+}
@@ -0,0 +1,340 @@
+package pulse8
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/chbmuc/cec"
+)
+
+// how long to wait for the adapter to answer a command
+const commandTimeout = time.Second
+
+// DefaultLineTimeout - the signal free time (in bit periods) before a new
+// frame, as used by libcec
+const DefaultLineTimeout = 3
+
+var (
+	errTimeout  = fmt.Errorf("pulse8: no response from adapter: %w", cec.ErrTimeout)
+	errRejected = errors.New("pulse8: command rejected")
+	errClosed   = fmt.Errorf("pulse8: adapter closed: %w", cec.ErrAdapterLost)
+)
+
+// TransmitError - the adapter could not transmit a frame
+type TransmitError struct {
+	Code byte
+}
+
+func (e *TransmitError) Error() string {
+	switch e.Code {
+	case codeTransmitFailedAck:
+		return "pulse8: not acknowledged"
+	case codeTransmitFailedLine:
+		return "pulse8: line error"
+	case codeTransmitFailedTimeoutData, codeTransmitFailedTimeoutLine:
+		return "pulse8: transmit timeout"
+	}
+	return fmt.Sprintf("pulse8: transmit failed (code %d)", e.Code)
+}
+
+// Unwrap - the matching cec error (cec.ErrNotAcknowledged, cec.ErrTimeout
+// or cec.ErrTransmitFailed)
+func (e *TransmitError) Unwrap() error {
+	switch e.Code {
+	case codeTransmitFailedAck:
+		return cec.ErrNotAcknowledged
+	case codeTransmitFailedTimeoutData, codeTransmitFailedTimeoutLine:
+		return cec.ErrTimeout
+	}
+	return cec.ErrTransmitFailed
+}
+
+// PersistentConfig - the configuration the adapter keeps in its EEPROM and
+// uses when no host is connected
+type PersistentConfig struct {
+	AutoEnabled           bool
+	DefaultLogicalAddress int
+	LogicalAddressMask    uint16
+	PhysicalAddress       uint16
+	DeviceType            byte
+	OSDName               string
+	HDMIVersion           byte
+}
+
+// Adapter - the command interface of a Pulse-Eight USB-CEC adapter on top
+// of its serial port (or anything emulating it, like a pseudo-terminal)
+type Adapter struct {
+	port io.ReadWriteCloser
+
+	// commands are sent one at a time, responses come from the reader
+	command   sync.Mutex
+	responses chan message
+	frames    chan []byte
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+// NewAdapter - start talking to an adapter on the given port. Received CEC
+// frames are available from Frames.
+func NewAdapter(port io.ReadWriteCloser) *Adapter {
+	a := &Adapter{
+		port:      port,
+		responses: make(chan message, 16),
+		frames:    make(chan []byte, 64),
+		closed:    make(chan struct{}),
+	}
+	go a.read()
+	return a
+}
+
+// Frames - the CEC frames received by the adapter. The channel is closed
+// when the adapter is closed or the port fails.
+func (a *Adapter) Frames() <-chan []byte {
+	return a.frames
+}
+
+// Close - close the serial port
+func (a *Adapter) Close() error {
+	var err error
+	a.closeOnce.Do(func() {
+		close(a.closed)
+		err = a.port.Close()
+	})
+	return err
+}
+
+// read - parse the byte stream, route received frames to Frames and
+// everything else to the command waiting for a response
+func (a *Adapter) read() {
+	defer close(a.frames)
+
+	var p parser
+	var frame []byte
+	buf := make([]byte, 256)
+
+	for {
+		n, err := a.port.Read(buf)
+		if err != nil {
+			a.Close()
+			return
+		}
+
+		for _, m := range p.feed(buf[:n]) {
+			switch m.code {
+			case codeFrameStart:
+				frame = append(frame[:0], m.params...)
+			case codeFrameData:
+				frame = append(frame, m.params...)
+			case codeHighError, codeLowError, codeReceiveFailed:
+				frame = frame[:0]
+				continue
+			default:
+				select {
+				case a.responses <- m:
+				default:
+					// nobody is waiting, drop the stale response
+				}
+				continue
+			}
+
+			if m.eom && len(frame) > 0 {
+				select {
+				case a.frames <- append([]byte(nil), frame...):
+				default:
+					// the backend is not keeping up, drop the frame
+				}
+				frame = frame[:0]
+			}
+		}
+	}
+}
+
+// write - send messages to the adapter in one write
+func (a *Adapter) write(messages ...message) error {
+	var out []byte
+	for _, m := range messages {
+		out = append(out, m.encode()...)
+	}
+	_, err := a.port.Write(out)
+	return err
+}
+
+// flush - drop responses left over from an earlier command
+func (a *Adapter) flush() {
+	for {
+		select {
+		case <-a.responses:
+		default:
+			return
+		}
+	}
+}
+
+// wait - wait for a response matching fn
+func (a *Adapter) wait(timeout time.Duration, fn func(m message) (done bool, err error)) (message, error) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for {
+		select {
+		case m := <-a.responses:
+			if done, err := fn(m); done {
+				return m, err
+			}
+		case <-timer.C:
+			return message{}, errTimeout
+		case <-a.closed:
+			return message{}, errClosed
+		}
+	}
+}
+
+// do - send a command and wait for the response with the given code (or
+// for the command to be accepted)
+func (a *Adapter) do(m message, reply byte) (message, error) {
+	a.command.Lock()
+	defer a.command.Unlock()
+
+	a.flush()
+	if err := a.write(m); err != nil {
+		return message{}, err
+	}
+
+	return a.wait(commandTimeout, func(r message) (bool, error) {
+		if r.code == codeCommandRejected && (len(r.params) == 0 || r.params[0] == m.code) {
+			return true, errRejected
+		}
+		if r.code == reply && (reply != codeCommandAccepted || len(r.params) == 0 || r.params[0] == m.code) {
+			return true, nil
+		}
+		return false, nil
+	})
+}
+
+// Ping - check that the adapter responds
+func (a *Adapter) Ping() error {
+	_, err := a.do(message{code: codePing}, codeCommandAccepted)
+	return err
+}
+
+// FirmwareVersion - the firmware version of the adapter
+func (a *Adapter) FirmwareVersion() (uint16, error) {
+	r, err := a.do(message{code: codeFirmwareVersion}, codeFirmwareVersion)
+	if err != nil {
+		return 0, err
+	}
+	if len(r.params) < 2 {
+		return 0, errors.New("pulse8: short firmware version")
+	}
+	return uint16(r.params[0])<<8 | uint16(r.params[1]), nil
+}
+
+// BuildDate - the build date of the firmware
+func (a *Adapter) BuildDate() (time.Time, error) {
+	r, err := a.do(message{code: codeGetBuildDate}, codeGetBuildDate)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if len(r.params) < 4 {
+		return time.Time{}, errors.New("pulse8: short build date")
+	}
+	seconds := int64(r.params[0])<<24 | int64(r.params[1])<<16 | int64(r.params[2])<<8 | int64(r.params[3])
+	return time.Unix(seconds, 0), nil
+}
+
+// SetControlled - in controlled mode the adapter leaves all CEC handling
+// to the host, otherwise it acts on its persistent configuration
+func (a *Adapter) SetControlled(controlled bool) error {
+	_, err := a.do(message{code: codeSetControlled, params: []byte{boolByte(controlled)}}, codeCommandAccepted)
+	return err
+}
+
+// SetAckMask - the logical addresses (one bit each) the adapter
+// acknowledges frames for
+func (a *Adapter) SetAckMask(mask uint16) error {
+	_, err := a.do(message{code: codeSetAckMask, params: []byte{byte(mask >> 8), byte(mask)}}, codeCommandAccepted)
+	return err
+}
+
+// Transmit - send a CEC frame after waiting lineTimeout bit periods for a
+// free line. Returns a *TransmitError if the frame was not acknowledged.
+func (a *Adapter) Transmit(frame []byte, lineTimeout byte) error {
+	if len(frame) == 0 || len(frame) > 16 {
+		return fmt.Errorf("pulse8: %w: %d bytes", cec.ErrInvalidFrame, len(frame))
+	}
+
+	messages := []message{{code: codeTransmitIdleTime, params: []byte{lineTimeout}}}
+
+	polarity := byte(0)
+	if frame[0]&0xF == 0xF {
+		polarity = 1
+	}
+	messages = append(messages, message{code: codeTransmitAckPolarity, params: []byte{polarity}})
+
+	for i, b := range frame {
+		code := byte(codeTransmit)
+		if i == len(frame)-1 {
+			code = codeTransmitEOM
+		}
+		messages = append(messages, message{code: code, params: []byte{b}})
+	}
+
+	a.command.Lock()
+	defer a.command.Unlock()
+
+	a.flush()
+	if err := a.write(messages...); err != nil {
+		return err
+	}
+
+	// every message is accepted on its own, then the result of the
+	// transmission follows (a frame takes at most ~100ms on the bus)
+	_, err := a.wait(commandTimeout, func(r message) (bool, error) {
+		switch r.code {
+		case codeTransmitSucceeded:
+			return true, nil
+		case codeTransmitFailedAck, codeTransmitFailedLine, codeTransmitFailedTimeoutData, codeTransmitFailedTimeoutLine:
+			return true, &TransmitError{Code: r.code}
+		case codeCommandRejected:
+			return true, errRejected
+		}
+		return false, nil
+	})
+	return err
+}
+
+// PersistConfig - write the configuration to the adapter's EEPROM
+func (a *Adapter) PersistConfig(c PersistentConfig) error {
+	name := c.OSDName
+	if len(name) > 13 {
+		name = name[:13]
+	}
+
+	commands := []message{
+		{code: codeSetAutoEnabled, params: []byte{boolByte(c.AutoEnabled)}},
+		{code: codeSetDefaultLogicalAddress, params: []byte{byte(c.DefaultLogicalAddress)}},
+		{code: codeSetLogicalAddressMask, params: []byte{byte(c.LogicalAddressMask >> 8), byte(c.LogicalAddressMask)}},
+		{code: codeSetPhysicalAddress, params: []byte{byte(c.PhysicalAddress >> 8), byte(c.PhysicalAddress)}},
+		{code: codeSetDeviceType, params: []byte{c.DeviceType}},
+		{code: codeSetOSDName, params: []byte(name)},
+		{code: codeSetHDMIVersion, params: []byte{c.HDMIVersion}},
+		{code: codeWriteEEPROM},
+	}
+
+	for _, m := range commands {
+		if _, err := a.do(m, codeCommandAccepted); err != nil {
+			return fmt.Errorf("pulse8: persist config (code %d): %w", m.code, err)
+		}
+	}
+	return nil
+}
+
+func boolByte(b bool) byte {
+	if b {
+		return 1
+	}
+	return 0
+}
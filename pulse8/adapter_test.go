@@ -0,0 +1,151 @@
+package pulse8
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/chbmuc/cec"
+)
+
+func TestAdapterCommands(t *testing.T) {
+	e, host := newEmulator(t)
+	a := NewAdapter(host)
+	defer a.Close()
+
+	if err := a.Ping(); err != nil {
+		t.Fatalf("Ping: %v", err)
+	}
+	firmware, err := a.FirmwareVersion()
+	if err != nil || firmware != 8 {
+		t.Errorf("FirmwareVersion = %d, %v, want 8", firmware, err)
+	}
+	if err := a.SetAckMask(1 << 4); err != nil {
+		t.Fatalf("SetAckMask: %v", err)
+	}
+	e.mu.Lock()
+	mask := e.ackMask
+	e.reject[codeSetControlled] = true
+	e.mu.Unlock()
+	if mask != 1<<4 {
+		t.Errorf("ack mask = %#x, want %#x", mask, 1<<4)
+	}
+	if err := a.SetControlled(true); !errors.Is(err, errRejected) {
+		t.Errorf("rejected SetControlled = %v, want errRejected", err)
+	}
+}
+
+func TestAdapterTransmit(t *testing.T) {
+	e, host := newEmulator(t)
+	a := NewAdapter(host)
+	defer a.Close()
+
+	if err := a.Transmit([]byte{0x40, 0x04}, DefaultLineTimeout); err != nil {
+		t.Fatalf("Transmit: %v", err)
+	}
+	if err := a.Transmit([]byte{0x4F, 0x82, 0x10, 0x00}, DefaultLineTimeout); err != nil {
+		t.Fatalf("Transmit broadcast: %v", err)
+	}
+
+	e.mu.Lock()
+	received := append([]message(nil), e.received...)
+	frames := append([][]byte(nil), e.frames...)
+	polarity := append([]byte(nil), e.polarity...)
+	e.mu.Unlock()
+
+	// idle time, ack polarity, then the bytes with EOM on the last one
+	want := []message{
+		{code: codeTransmitIdleTime, params: []byte{DefaultLineTimeout}},
+		{code: codeTransmitAckPolarity, params: []byte{0}},
+		{code: codeTransmit, params: []byte{0x40}},
+		{code: codeTransmitEOM, params: []byte{0x04}},
+	}
+	for i, m := range want {
+		if i >= len(received) || received[i].code != m.code || !bytes.Equal(received[i].params, m.params) {
+			t.Fatalf("message %d = %v, want %v", i, received[i], m)
+		}
+	}
+	if len(frames) != 2 || !bytes.Equal(frames[0], []byte{0x40, 0x04}) || !bytes.Equal(frames[1], []byte{0x4F, 0x82, 0x10, 0x00}) {
+		t.Errorf("transmitted % x", frames)
+	}
+	// broadcasts use the inverted acknowledge bit
+	if !bytes.Equal(polarity, []byte{0, 1}) {
+		t.Errorf("ack polarity % x, want 00 01", polarity)
+	}
+}
+
+func TestAdapterTransmitErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		result byte
+		want   error
+	}{
+		{name: "nack", result: codeTransmitFailedAck, want: cec.ErrNotAcknowledged},
+		{name: "line", result: codeTransmitFailedLine, want: cec.ErrTransmitFailed},
+		{name: "data timeout", result: codeTransmitFailedTimeoutData, want: cec.ErrTimeout},
+		{name: "line timeout", result: codeTransmitFailedTimeoutLine, want: cec.ErrTimeout},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, host := newEmulator(t)
+			e.bus = func([]byte) (byte, [][]byte) { return tt.result, nil }
+			a := NewAdapter(host)
+			defer a.Close()
+
+			err := a.Transmit([]byte{0x40, 0x04}, DefaultLineTimeout)
+			var te *TransmitError
+			if !errors.As(err, &te) || te.Code != tt.result {
+				t.Errorf("Transmit = %v, want a TransmitError with code %d", err, tt.result)
+			}
+			if !errors.Is(err, tt.want) {
+				t.Errorf("Transmit = %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	_, host := newEmulator(t)
+	a := NewAdapter(host)
+	defer a.Close()
+	if err := a.Transmit(nil, DefaultLineTimeout); !errors.Is(err, cec.ErrInvalidFrame) {
+		t.Errorf("Transmit of an empty frame = %v, want ErrInvalidFrame", err)
+	}
+	if err := a.Transmit(make([]byte, 17), DefaultLineTimeout); !errors.Is(err, cec.ErrInvalidFrame) {
+		t.Errorf("Transmit of 17 bytes = %v, want ErrInvalidFrame", err)
+	}
+}
+
+func TestAdapterFrames(t *testing.T) {
+	e, host := newEmulator(t)
+	a := NewAdapter(host)
+	defer a.Close()
+
+	// a frame with bytes that need escaping
+	e.receive([]byte{0x04, 0xFF, 0xFE, 0xFD})
+	// a frame broken by a receive error is dropped
+	e.send(message{code: codeFrameStart, params: []byte{0x04}}, message{code: codeFrameData, params: []byte{0x8F}},
+		message{code: codeReceiveFailed})
+	e.receive([]byte{0x0F, 0x36})
+
+	for _, want := range [][]byte{{0x04, 0xFF, 0xFE, 0xFD}, {0x0F, 0x36}} {
+		select {
+		case got := <-a.Frames():
+			if !bytes.Equal(got, want) {
+				t.Errorf("frame % x, want % x", got, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("frame % x not received", want)
+		}
+	}
+
+	a.Close()
+	select {
+	case _, ok := <-a.Frames():
+		if ok {
+			t.Error("frame received after Close")
+		}
+	case <-time.After(time.Second):
+		t.Error("Frames not closed by Close")
+	}
+}
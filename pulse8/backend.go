@@ -0,0 +1,297 @@
+package pulse8
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/chbmuc/cec"
+	"github.com/chbmuc/cec/internal/node"
+)
+
+// how long to wait for the reply to a request
+const replyTimeout = time.Second
+
+// Pulse-Eight's IEEE OUI, reported in Device Vendor ID by default
+const vendorPulseEight = 0x001582
+
+// Config - how the adapter presents itself on the bus
+type Config struct {
+	// OSDName is reported in Set OSD Name (max 14 characters)
+	OSDName string
+	// DeviceType to claim a logical address for: TV, recording, tuner,
+	// playback or audio system (the zero value is cec.DeviceTypeTV)
+	DeviceType cec.DeviceType
+	// PhysicalAddress of the HDMI port the adapter is connected to
+	PhysicalAddress uint16
+	// VendorID is reported in Device Vendor ID (default Pulse-Eight)
+	VendorID uint32
+}
+
+// logical addresses to try for each device type
+var deviceTypes = map[cec.DeviceType][]int{
+	cec.DeviceTypeTV:          {0},
+	cec.DeviceTypeRecording:   {1, 2, 9},
+	cec.DeviceTypeTuner:       {3, 6, 7, 10},
+	cec.DeviceTypePlayback:    {4, 8, 11},
+	cec.DeviceTypeAudioSystem: {5},
+}
+
+// waiter - a request waiting for its reply
+type waiter struct {
+	from   int
+	opcode byte
+	reply  byte
+	ch     chan []byte
+	abort  chan byte
+}
+
+// Backend - cec.Backend implementation on top of a Pulse-Eight USB-CEC
+// adapter. The adapter runs in controlled mode, the backend claims a
+// logical address and answers the standard requests itself.
+type Backend struct {
+	*node.Node
+
+	adapter  *Adapter
+	line     *line
+	ctl      *node.Control
+	config   Config
+	firmware uint16
+	stopped  chan struct{}
+}
+
+// Open - open the adapter on the serial port at path (e.g. /dev/ttyACM0)
+func Open(path string, config Config) (*Backend, error) {
+	port, err := openPort(path)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := NewBackend(port, config)
+	if err != nil {
+		port.Close()
+		return nil, err
+	}
+	return b, nil
+}
+
+// NewBackend - initialise the adapter on the given port and claim a
+// logical address for the configured device type
+func NewBackend(port io.ReadWriteCloser, config Config) (*Backend, error) {
+	if config.VendorID == 0 {
+		config.VendorID = vendorPulseEight
+	}
+	addresses, ok := deviceTypes[config.DeviceType]
+	if !ok {
+		return nil, fmt.Errorf("pulse8: unsupported device type %s", config.DeviceType)
+	}
+
+	adapter := NewAdapter(port)
+	if err := adapter.Ping(); err != nil {
+		adapter.Close()
+		return nil, err
+	}
+	firmware, err := adapter.FirmwareVersion()
+	if err != nil {
+		adapter.Close()
+		return nil, err
+	}
+	if firmware >= 2 {
+		if err := adapter.SetControlled(true); err != nil {
+			adapter.Close()
+			return nil, err
+		}
+	}
+
+	b := &Backend{
+		adapter:  adapter,
+		line:     &line{adapter: adapter},
+		config:   config,
+		firmware: firmware,
+		stopped:  make(chan struct{}),
+	}
+	b.Node, b.ctl = node.New(b.line)
+	b.ctl.SetAddresses(0xF, config.PhysicalAddress)
+	b.ctl.SetOSDName(config.OSDName)
+	b.ctl.SetVendorID(config.VendorID)
+
+	go b.receive()
+
+	if err := b.claim(addresses); err != nil {
+		b.Close()
+		return nil, err
+	}
+
+	return b, nil
+}
+
+// Adapter - the adapter, e.g. to persist the configuration
+func (b *Backend) Adapter() *Adapter {
+	return b.adapter
+}
+
+// FirmwareVersion - the firmware version reported by the adapter
+func (b *Backend) FirmwareVersion() uint16 {
+	return b.firmware
+}
+
+// claim - poll the candidate addresses, take the first one nobody
+// acknowledges and announce it. Any other transmit failure (line error,
+// timeout) says nothing about the address and is returned.
+func (b *Backend) claim(addresses []int) error {
+	address := 0xF
+	for _, candidate := range addresses {
+		err := b.adapter.Transmit([]byte{byte(candidate<<4 | candidate)}, 5)
+		var te *TransmitError
+		if errors.As(err, &te) && te.Code == codeTransmitFailedAck {
+			address = candidate
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	b.ctl.SetAddresses(address, b.config.PhysicalAddress)
+
+	if err := b.adapter.SetAckMask(1 << uint(address)); err != nil {
+		return err
+	}
+
+	return b.reportPhysicalAddress()
+}
+
+func (b *Backend) reportPhysicalAddress() error {
+	pa := b.config.PhysicalAddress
+	return b.ctl.Send(0xF, 0x84, byte(pa>>8), byte(pa), byte(b.config.DeviceType))
+}
+
+// Close - release the adapter
+func (b *Backend) Close() error {
+	if !b.ctl.Stop() {
+		return nil
+	}
+
+	if b.firmware >= 2 {
+		b.adapter.SetControlled(false)
+	}
+	err := b.adapter.Close()
+	<-b.stopped
+	return err
+}
+
+// receive - handle received frames until the adapter is closed
+func (b *Backend) receive() {
+	defer close(b.stopped)
+
+	for frame := range b.adapter.Frames() {
+		b.handleFrame(frame)
+	}
+
+	if !b.ctl.Stopped() {
+		b.ctl.Push(cec.Alert{Type: "CONNECTION_LOST", Timestamp: time.Now()})
+	}
+}
+
+// handleFrame - complete waiting requests, answer the standard requests
+// and report the frame as callback events
+func (b *Backend) handleFrame(frame []byte) {
+	b.ctl.Traffic(frame, false)
+	if len(frame) < 2 {
+		return
+	}
+
+	initiator := int(frame[0] >> 4)
+	destination := int(frame[0] & 0xF)
+	opcode := frame[1]
+
+	b.line.complete(initiator, opcode, frame[2:])
+
+	if destination == b.LogicalAddress() {
+		switch opcode {
+		case 0x83: // give physical address
+			b.reportPhysicalAddress()
+		case 0x46: // give OSD name
+			b.ctl.Send(initiator, 0x47, []byte(b.config.OSDName)...)
+		case 0x8C: // give device vendor id
+			v := b.config.VendorID
+			b.ctl.Send(0xF, 0x87, byte(v>>16), byte(v>>8), byte(v))
+		case 0x9F: // get CEC version
+			b.ctl.Send(initiator, 0x9E, 0x05)
+		case 0xFF: // abort
+			b.ctl.Send(initiator, 0x00, 0xFF, 0x04)
+		}
+	}
+
+	b.ctl.Handle(frame)
+}
+
+// line - the node's transport, transmits through the adapter and matches
+// received frames to the requests waiting for a reply
+type line struct {
+	adapter *Adapter
+
+	mu      sync.Mutex
+	waiters []*waiter
+}
+
+func (l *line) Transmit(frame []byte) error {
+	return l.adapter.Transmit(frame, DefaultLineTimeout)
+}
+
+func (l *line) Request(frame []byte, reply byte) ([]byte, error) {
+	if len(frame) < 2 {
+		return nil, fmt.Errorf("pulse8: %w: request without opcode", cec.ErrInvalidFrame)
+	}
+	w := &waiter{from: int(frame[0] & 0xF), opcode: frame[1], reply: reply, ch: make(chan []byte, 1), abort: make(chan byte, 1)}
+
+	l.mu.Lock()
+	l.waiters = append(l.waiters, w)
+	l.mu.Unlock()
+
+	defer func() {
+		l.mu.Lock()
+		for i, other := range l.waiters {
+			if other == w {
+				l.waiters = append(l.waiters[:i], l.waiters[i+1:]...)
+				break
+			}
+		}
+		l.mu.Unlock()
+	}()
+
+	if err := l.Transmit(frame); err != nil {
+		return nil, err
+	}
+
+	select {
+	case params := <-w.ch:
+		return params, nil
+	case reason := <-w.abort:
+		return nil, &cec.FeatureAbortError{Opcode: cec.Opcode(w.opcode), Reason: reason}
+	case <-time.After(replyTimeout):
+		return nil, fmt.Errorf("pulse8: no reply: %w", cec.ErrTimeout)
+	}
+}
+
+// complete - hand a received frame to the requests waiting for it
+func (l *line) complete(initiator int, opcode byte, params []byte) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, w := range l.waiters {
+		if w.from != initiator && w.from != 0xF {
+			continue
+		}
+		if opcode == w.reply {
+			w.ch <- append([]byte{}, params...)
+		} else if opcode == 0x00 && len(params) >= 2 && params[0] == w.opcode {
+			w.abort <- params[1]
+		} else {
+			continue
+		}
+		w.from = -1
+	}
+}
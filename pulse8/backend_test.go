@@ -0,0 +1,190 @@
+package pulse8
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/chbmuc/cec"
+)
+
+// devices - a bus with devices at the given logical addresses, answering
+// Give OSD Name with "Device" and polls with an acknowledgement
+func devices(addresses ...int) func(frame []byte) (byte, [][]byte) {
+	present := make(map[int]bool)
+	for _, a := range addresses {
+		present[a] = true
+	}
+	return func(frame []byte) (byte, [][]byte) {
+		initiator, destination := int(frame[0]>>4), int(frame[0]&0xF)
+		if destination == 0xF {
+			return codeTransmitSucceeded, nil
+		}
+		if !present[destination] {
+			return codeTransmitFailedAck, nil
+		}
+		if len(frame) > 1 && frame[1] == 0x46 {
+			return codeTransmitSucceeded, [][]byte{append([]byte{byte(destination<<4 | initiator), 0x47}, "Device"...)}
+		}
+		return codeTransmitSucceeded, nil
+	}
+}
+
+func openBackend(t *testing.T, host io.ReadWriteCloser, config Config) *Backend {
+	t.Helper()
+
+	b, err := NewBackend(host, config)
+	if err != nil {
+		t.Fatalf("NewBackend: %v", err)
+	}
+	t.Cleanup(func() { b.Close() })
+	return b
+}
+
+func TestClaim(t *testing.T) {
+	e, host := newEmulator(t)
+	e.bus = devices(0, 4)
+	b := openBackend(t, host, Config{OSDName: "cec.go", DeviceType: cec.DeviceTypePlayback, PhysicalAddress: 0x1000})
+
+	if got := b.LogicalAddress(); got != 8 {
+		t.Errorf("logical address = %d, want 8 (4 acknowledged its poll)", got)
+	}
+	e.mu.Lock()
+	mask := e.ackMask
+	e.mu.Unlock()
+	if mask != 1<<8 {
+		t.Errorf("ack mask = %#x, want %#x", mask, 1<<8)
+	}
+
+	frames := e.transmitted()
+	want := [][]byte{{0x44}, {0x88}, {0x8F, 0x84, 0x10, 0x00, 0x04}}
+	if len(frames) != len(want) {
+		t.Fatalf("transmitted % x, want % x", frames, want)
+	}
+	for i := range want {
+		if !bytes.Equal(frames[i], want[i]) {
+			t.Errorf("frame %d = % x, want % x", i, frames[i], want[i])
+		}
+	}
+}
+
+func TestClaimAllTaken(t *testing.T) {
+	e, host := newEmulator(t)
+	e.bus = devices(5)
+	b := openBackend(t, host, Config{DeviceType: cec.DeviceTypeAudioSystem})
+
+	if got := b.LogicalAddress(); got != 0xF {
+		t.Errorf("logical address = %d, want unregistered", got)
+	}
+}
+
+func TestClaimLineError(t *testing.T) {
+	e, host := newEmulator(t)
+	e.bus = func([]byte) (byte, [][]byte) { return codeTransmitFailedLine, nil }
+
+	b, err := NewBackend(host, Config{DeviceType: cec.DeviceTypePlayback})
+	if err == nil {
+		b.Close()
+		t.Fatalf("claimed %d although the line failed", b.LogicalAddress())
+	}
+	if !errors.Is(err, cec.ErrTransmitFailed) {
+		t.Errorf("NewBackend = %v, want ErrTransmitFailed", err)
+	}
+}
+
+func TestUnsupportedDeviceType(t *testing.T) {
+	for _, dt := range []cec.DeviceType{cec.DeviceTypeReserved, cec.DeviceTypeProcessor, 0x10} {
+		e, host := newEmulator(t)
+		if b, err := NewBackend(host, Config{DeviceType: dt}); err == nil {
+			b.Close()
+			t.Errorf("NewBackend accepted device type %s", dt)
+		}
+		if frames := e.transmitted(); len(frames) != 0 {
+			t.Errorf("device type %s transmitted % x", dt, frames)
+		}
+	}
+}
+
+func TestRequests(t *testing.T) {
+	e, host := newEmulator(t)
+	e.bus = devices(0)
+	b := openBackend(t, host, Config{OSDName: "cec.go", DeviceType: cec.DeviceTypePlayback, PhysicalAddress: 0x1000})
+
+	if got := b.GetDeviceOSDName(0); got != "Device" {
+		t.Errorf("OSD name = %q, want Device", got)
+	}
+	if got := b.GetDeviceOSDName(4); got != "cec.go" {
+		t.Errorf("own OSD name = %q, want cec.go", got)
+	}
+	if got := b.GetDevicePhysicalAddress(4); got != 0x1000 {
+		t.Errorf("own physical address = %04x, want 1000", got)
+	}
+	if got := b.GetDevicePowerStatus(3); got != 0x99 {
+		t.Errorf("power status of a missing device = %#x, want unknown", got)
+	}
+	if !b.PollDevice(0) || b.PollDevice(3) {
+		t.Error("PollDevice does not follow the acknowledgement")
+	}
+}
+
+func TestBuiltinReplies(t *testing.T) {
+	e, host := newEmulator(t)
+	e.bus = devices(0)
+	b := openBackend(t, host, Config{OSDName: "cec.go", DeviceType: cec.DeviceTypePlayback, PhysicalAddress: 0x1000})
+
+	var mu sync.Mutex
+	var activated bool
+	b.SetEventHandler(func(event interface{}) {
+		if s, ok := event.(cec.SourceActivated); ok && s.Active {
+			mu.Lock()
+			activated = true
+			mu.Unlock()
+		}
+	})
+
+	e.receive([]byte{0x04, 0x46})
+	e.receive([]byte{0x04, 0x8F})
+	e.receive([]byte{0x0F, 0x86, 0x10, 0x00})
+
+	want := [][]byte{append([]byte{0x40, 0x47}, "cec.go"...), {0x40, 0x90, 0x00}, {0x4F, 0x82, 0x10, 0x00}}
+	deadline := time.Now().Add(time.Second)
+	for _, w := range want {
+		for !containsFrame(e.transmitted(), w) {
+			if time.Now().After(deadline) {
+				t.Fatalf("% x not transmitted, got % x", w, e.transmitted())
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+
+	for b.GetActiveSource() != 4 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if got := b.GetActiveSource(); got != 4 {
+		t.Errorf("active source = %d, want 4", got)
+	}
+	for {
+		mu.Lock()
+		done := activated
+		mu.Unlock()
+		if done {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("no SourceActivated event")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func containsFrame(frames [][]byte, frame []byte) bool {
+	for _, f := range frames {
+		if bytes.Equal(f, frame) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,138 @@
+package pulse8
+
+import (
+	"io"
+	"net"
+	"sync"
+	"testing"
+)
+
+// emulator - the adapter end of the serial line. It accepts commands like
+// the firmware does and puts transmitted frames on a simulated bus.
+type emulator struct {
+	conn io.ReadWriter
+
+	mu       sync.Mutex
+	received []message // every message from the host
+	frames   [][]byte  // transmitted frames
+	polarity []byte    // ack polarity of each transmitted frame
+	ackMask  uint16
+	pending  []byte
+	firmware uint16
+
+	// bus - the result code of a transmitted frame and the frames other
+	// devices send in reply. Without it every frame succeeds.
+	bus func(frame []byte) (result byte, replies [][]byte)
+	// reject - command codes the emulator rejects
+	reject map[byte]bool
+}
+
+// newEmulator - an adapter connected to the returned host end
+func newEmulator(t *testing.T) (*emulator, net.Conn) {
+	host, adapter := net.Pipe()
+	t.Cleanup(func() { adapter.Close() })
+	return emulate(adapter), host
+}
+
+// emulate - an adapter on the given end of the line, it stops when the
+// line is closed
+func emulate(conn io.ReadWriter) *emulator {
+	e := &emulator{conn: conn, firmware: 8, reject: make(map[byte]bool)}
+	go e.run()
+	return e
+}
+
+func (e *emulator) run() {
+	var p parser
+	buf := make([]byte, 256)
+	for {
+		n, err := e.conn.Read(buf)
+		if err != nil {
+			return
+		}
+		for _, m := range p.feed(buf[:n]) {
+			e.handle(m)
+		}
+	}
+}
+
+func (e *emulator) send(messages ...message) {
+	var out []byte
+	for _, m := range messages {
+		out = append(out, m.encode()...)
+	}
+	e.conn.Write(out)
+}
+
+func accepted(code byte) message {
+	return message{code: codeCommandAccepted, params: []byte{code}}
+}
+
+func (e *emulator) handle(m message) {
+	e.mu.Lock()
+	e.received = append(e.received, m)
+	if e.reject[m.code] {
+		e.mu.Unlock()
+		e.send(message{code: codeCommandRejected, params: []byte{m.code}})
+		return
+	}
+
+	var out []message
+	switch m.code {
+	case codePing, codeSetControlled, codeTransmitIdleTime:
+		out = append(out, accepted(m.code))
+	case codeFirmwareVersion:
+		out = append(out, message{code: codeFirmwareVersion, params: []byte{byte(e.firmware >> 8), byte(e.firmware)}})
+	case codeSetAckMask:
+		e.ackMask = uint16(m.params[0])<<8 | uint16(m.params[1])
+		out = append(out, accepted(m.code))
+	case codeTransmitAckPolarity:
+		e.polarity = append(e.polarity, m.params[0])
+		out = append(out, accepted(m.code))
+	case codeTransmit:
+		e.pending = append(e.pending, m.params...)
+		out = append(out, accepted(m.code))
+	case codeTransmitEOM:
+		frame := append(e.pending, m.params...)
+		e.pending = nil
+		e.frames = append(e.frames, frame)
+		result, replies := byte(codeTransmitSucceeded), [][]byte(nil)
+		if e.bus != nil {
+			result, replies = e.bus(frame)
+		}
+		out = append(out, accepted(m.code), message{code: result})
+		for _, reply := range replies {
+			out = append(out, frameMessages(reply)...)
+		}
+	default:
+		out = append(out, accepted(m.code))
+	}
+	e.mu.Unlock()
+
+	e.send(out...)
+}
+
+// frameMessages - a received CEC frame as the adapter reports it
+func frameMessages(frame []byte) []message {
+	var messages []message
+	for i, b := range frame {
+		code := byte(codeFrameData)
+		if i == 0 {
+			code = codeFrameStart
+		}
+		messages = append(messages, message{code: code, eom: i == len(frame)-1, ack: true, params: []byte{b}})
+	}
+	return messages
+}
+
+// receive - a frame from another device on the bus
+func (e *emulator) receive(frame []byte) {
+	e.send(frameMessages(frame)...)
+}
+
+func (e *emulator) transmitted() [][]byte {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return append([][]byte(nil), e.frames...)
+}
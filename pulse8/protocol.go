@@ -0,0 +1,150 @@
+// Package pulse8 is a cgo free backend for the cec package that speaks the
+// serial protocol of the Pulse-Eight USB-CEC adapter (/dev/ttyACM*).
+//
+//	backend, err := pulse8.Open("/dev/ttyACM0", pulse8.Config{OSDName: "cec.go", DeviceType: cec.DeviceTypePlayback, PhysicalAddress: 0x1000})
+//	conn, err := cec.OpenBackend(backend)
+package pulse8
+
+import (
+	"fmt"
+)
+
+// framing bytes
+const (
+	msgStart  = 0xFF
+	msgEnd    = 0xFE
+	msgEsc    = 0xFD
+	escOffset = 3
+)
+
+// flags in the message code byte
+const (
+	flagEOM  = 0x80
+	flagACK  = 0x40
+	codeMask = 0x3F
+)
+
+// message codes
+const (
+	codeNothing                   = 0
+	codePing                      = 1
+	codeTimeoutError              = 2
+	codeHighError                 = 3
+	codeLowError                  = 4
+	codeFrameStart                = 5
+	codeFrameData                 = 6
+	codeReceiveFailed             = 7
+	codeCommandAccepted           = 8
+	codeCommandRejected           = 9
+	codeSetAckMask                = 10
+	codeTransmit                  = 11
+	codeTransmitEOM               = 12
+	codeTransmitIdleTime          = 13
+	codeTransmitAckPolarity       = 14
+	codeTransmitLineTimeout       = 15
+	codeTransmitSucceeded         = 16
+	codeTransmitFailedLine        = 17
+	codeTransmitFailedAck         = 18
+	codeTransmitFailedTimeoutData = 19
+	codeTransmitFailedTimeoutLine = 20
+	codeFirmwareVersion           = 21
+	codeStartBootloader           = 22
+	codeGetBuildDate              = 23
+	codeSetControlled             = 24
+	codeGetAutoEnabled            = 25
+	codeSetAutoEnabled            = 26
+	codeGetDefaultLogicalAddress  = 27
+	codeSetDefaultLogicalAddress  = 28
+	codeGetLogicalAddressMask     = 29
+	codeSetLogicalAddressMask     = 30
+	codeGetPhysicalAddress        = 31
+	codeSetPhysicalAddress        = 32
+	codeGetDeviceType             = 33
+	codeSetDeviceType             = 34
+	codeGetHDMIVersion            = 35
+	codeSetHDMIVersion            = 36
+	codeGetOSDName                = 37
+	codeSetOSDName                = 38
+	codeWriteEEPROM               = 39
+	codeGetAdapterType            = 40
+)
+
+// message - one message to or from the adapter
+type message struct {
+	code   byte
+	eom    bool
+	ack    bool
+	params []byte
+}
+
+func (m message) String() string {
+	return fmt.Sprintf("code %d eom %v ack %v params % x", m.code, m.eom, m.ack, m.params)
+}
+
+func appendEscaped(out []byte, b byte) []byte {
+	if b >= msgEsc {
+		return append(out, msgEsc, b-escOffset)
+	}
+	return append(out, b)
+}
+
+// encode - frame and escape a message
+func (m message) encode() []byte {
+	code := m.code
+	if m.eom {
+		code |= flagEOM
+	}
+	if m.ack {
+		code |= flagACK
+	}
+
+	out := []byte{msgStart}
+	out = appendEscaped(out, code)
+	for _, b := range m.params {
+		out = appendEscaped(out, b)
+	}
+	return append(out, msgEnd)
+}
+
+// parser - reassembles messages from the serial byte stream
+type parser struct {
+	buf     []byte
+	started bool
+	escaped bool
+}
+
+// feed - add received bytes, returns the complete messages
+func (p *parser) feed(data []byte) []message {
+	var messages []message
+
+	for _, b := range data {
+		switch {
+		case b == msgStart:
+			p.buf = p.buf[:0]
+			p.started = true
+			p.escaped = false
+		case !p.started:
+			// garbage between messages
+		case b == msgEnd:
+			if len(p.buf) > 0 {
+				code := p.buf[0]
+				messages = append(messages, message{
+					code:   code & codeMask,
+					eom:    code&flagEOM != 0,
+					ack:    code&flagACK != 0,
+					params: append([]byte(nil), p.buf[1:]...),
+				})
+			}
+			p.started = false
+		case b == msgEsc:
+			p.escaped = true
+		case p.escaped:
+			p.buf = append(p.buf, b+escOffset)
+			p.escaped = false
+		default:
+			p.buf = append(p.buf, b)
+		}
+	}
+
+	return messages
+}
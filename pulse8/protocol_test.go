@@ -0,0 +1,75 @@
+package pulse8
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestEncode(t *testing.T) {
+	tests := []struct {
+		name string
+		m    message
+		want []byte
+	}{
+		{name: "ping", m: message{code: codePing}, want: []byte{0xFF, 0x01, 0xFE}},
+		{name: "flags", m: message{code: codeFrameStart, eom: true, ack: true, params: []byte{0x40}},
+			want: []byte{0xFF, 0xC5, 0x40, 0xFE}},
+		{name: "escaped", m: message{code: codeTransmit, params: []byte{0xFD, 0xFE, 0xFF, 0xFC}},
+			want: []byte{0xFF, 0x0B, 0xFD, 0xFA, 0xFD, 0xFB, 0xFD, 0xFC, 0xFC, 0xFE}},
+		{name: "escaped code", m: message{code: codeTransmitEOM, eom: true, ack: true, params: []byte{0x0F}},
+			want: []byte{0xFF, 0xCC, 0x0F, 0xFE}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.m.encode(); !bytes.Equal(got, tt.want) {
+				t.Errorf("encode = % x, want % x", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	stream := []byte{
+		0x12, 0x34, // garbage before the first message
+		0xFF, 0x08, 0x01, 0xFE, // command accepted (ping)
+		0xFF, 0x45, 0x4F, 0xFE, // frame start with ack
+		0xFF, 0xC6, 0xFD, 0xFC, 0xFE, // frame data 0xFF, eom and ack
+		0xFF, 0x06, 0x01, // truncated by the next start
+		0xFF, 0x10, 0xFE, // transmit succeeded
+		0xFF, 0xFE, // empty message, ignored
+	}
+	want := []message{
+		{code: codeCommandAccepted, params: []byte{codePing}},
+		{code: codeFrameStart, ack: true, params: []byte{0x4F}},
+		{code: codeFrameData, eom: true, ack: true, params: []byte{0xFF}},
+		{code: codeTransmitSucceeded},
+	}
+
+	// byte by byte, as a serial port may deliver it, and all at once
+	for _, size := range []int{1, len(stream)} {
+		var p parser
+		var got []message
+		for i := 0; i < len(stream); i += size {
+			end := i + size
+			if end > len(stream) {
+				end = len(stream)
+			}
+			got = append(got, p.feed(stream[i:end])...)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("chunks of %d: parsed %v, want %v", size, got, want)
+		}
+	}
+}
+
+func TestEncodeParse(t *testing.T) {
+	m := message{code: codeSetOSDName, params: []byte("\xFF\xFE\xFDname")}
+
+	var p parser
+	got := p.feed(m.encode())
+	if len(got) != 1 || !reflect.DeepEqual(got[0], m) {
+		t.Errorf("round trip of %v gave %v", m, got)
+	}
+}
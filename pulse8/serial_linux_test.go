@@ -0,0 +1,99 @@
+package pulse8
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+	"testing"
+	"unsafe"
+
+	"github.com/chbmuc/cec"
+)
+
+// openPTY - the master end of a pseudo terminal and the path of its slave,
+// which stands in for the adapter's serial port
+func openPTY(t *testing.T) (*os.File, string) {
+	t.Helper()
+
+	master, err := os.OpenFile("/dev/ptmx", os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		t.Skipf("no pseudo terminals: %v", err)
+	}
+	t.Cleanup(func() { master.Close() })
+
+	var unlock, number int32
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, master.Fd(), syscall.TIOCSPTLCK, uintptr(unsafe.Pointer(&unlock))); errno != 0 {
+		t.Fatalf("TIOCSPTLCK: %v", errno)
+	}
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, master.Fd(), syscall.TIOCGPTN, uintptr(unsafe.Pointer(&number))); errno != 0 {
+		t.Fatalf("TIOCGPTN: %v", errno)
+	}
+	return master, fmt.Sprintf("/dev/pts/%d", number)
+}
+
+func TestOpenPort(t *testing.T) {
+	_, path := openPTY(t)
+
+	port, err := openPort(path)
+	if err != nil {
+		t.Fatalf("openPort: %v", err)
+	}
+	defer port.Close()
+
+	var term syscall.Termios
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, port.Fd(), syscall.TCGETS, uintptr(unsafe.Pointer(&term))); errno != 0 {
+		t.Fatalf("TCGETS: %v", errno)
+	}
+	if term.Lflag&(syscall.ICANON|syscall.ECHO|syscall.ISIG) != 0 {
+		t.Errorf("lflag = %#o, want no line editing, echo or signals", term.Lflag)
+	}
+	if term.Iflag&(syscall.ICRNL|syscall.IXON) != 0 || term.Oflag&syscall.OPOST != 0 {
+		t.Errorf("iflag = %#o, oflag = %#o, want no translation", term.Iflag, term.Oflag)
+	}
+	if term.Cflag&syscall.CSIZE != syscall.CS8 || term.Cflag&(syscall.PARENB|syscall.CSTOPB) != 0 {
+		t.Errorf("cflag = %#o, want 8N1", term.Cflag)
+	}
+	if term.Cflag&cbaud != syscall.B38400 {
+		t.Errorf("baud rate = %#o, want B38400", term.Cflag&cbaud)
+	}
+	if term.Cc[syscall.VMIN] != 1 || term.Cc[syscall.VTIME] != 0 {
+		t.Errorf("VMIN = %d, VTIME = %d, want reads returning every byte", term.Cc[syscall.VMIN], term.Cc[syscall.VTIME])
+	}
+}
+
+func TestOpenPortMissing(t *testing.T) {
+	if _, err := openPort("/dev/pts/does-not-exist"); err == nil {
+		t.Error("openPort of a missing device succeeded")
+	}
+	// not a terminal, the line settings cannot be applied
+	if _, err := openPort(os.DevNull); err == nil {
+		t.Error("openPort of /dev/null succeeded")
+	}
+}
+
+func TestOpenPTY(t *testing.T) {
+	master, path := openPTY(t)
+	e := emulate(master)
+	e.bus = devices(0, 4)
+
+	b, err := Open(path, Config{OSDName: "cec.go", DeviceType: cec.DeviceTypePlayback, PhysicalAddress: 0x1000})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer b.Close()
+
+	if got := b.LogicalAddress(); got != 8 {
+		t.Errorf("logical address = %d, want 8", got)
+	}
+	// the protocol's control bytes (escape 0xFD, end 0xFE, start 0xFF) and
+	// a carriage return have to cross the line unchanged
+	if err := b.Transmit([]byte{0x80, 0x47, 0x0D, 0xFD, 0xFE, 0xFF}); err != nil {
+		t.Fatalf("Transmit: %v", err)
+	}
+	e.mu.Lock()
+	frames := e.frames
+	e.mu.Unlock()
+	if len(frames) == 0 || string(frames[len(frames)-1]) != "\x80\x47\x0D\xFD\xFE\xFF" {
+		t.Errorf("frames on the bus % x, want the last to be 80 47 0d fd fe ff", frames)
+	}
+}
@@ -0,0 +1,14 @@
+//go:build !linux
+
+package pulse8
+
+import (
+	"os"
+)
+
+// openPort - open the serial port, the line settings are left to the
+// operating system (the adapter is a USB CDC device, the baud rate is not
+// used)
+func openPort(path string) (*os.File, error) {
+	return os.OpenFile(path, os.O_RDWR, 0)
+}
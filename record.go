@@ -0,0 +1,214 @@
+package cec
+
+import (
+	"context"
+	"fmt"
+)
+
+// RecordSourceType - what a recorder records
+type RecordSourceType byte
+
+// record source types as used on the bus
+const (
+	RecordOwnSource               RecordSourceType = 0x01
+	RecordDigitalService          RecordSourceType = 0x02
+	RecordAnalogueService         RecordSourceType = 0x03
+	RecordExternalPlug            RecordSourceType = 0x04
+	RecordExternalPhysicalAddress RecordSourceType = 0x05
+)
+
+var recordSourceTypeNames = map[RecordSourceType]string{
+	RecordOwnSource: "own source", RecordDigitalService: "digital service",
+	RecordAnalogueService: "analogue service", RecordExternalPlug: "external plug",
+	RecordExternalPhysicalAddress: "external physical address"}
+
+// IsValid - whether the type is defined by the standard
+func (t RecordSourceType) IsValid() bool {
+	_, ok := recordSourceTypeNames[t]
+	return ok
+}
+
+func (t RecordSourceType) String() string {
+	if name, ok := recordSourceTypeNames[t]; ok {
+		return name
+	}
+	return "Unknown"
+}
+
+// RecordSource - the source of Record On, only the field for the Type is
+// used
+type RecordSource struct {
+	Type     RecordSourceType
+	Digital  DigitalService
+	Analogue AnalogueService
+	// Plug - the external plug (1-255) of the recorder
+	Plug byte
+	// Addr - the physical address of an external source
+	Addr PhysicalAddress
+}
+
+func (s RecordSource) String() string {
+	switch s.Type {
+	case RecordDigitalService:
+		return s.Digital.String()
+	case RecordAnalogueService:
+		return s.Analogue.String()
+	case RecordExternalPlug:
+		return fmt.Sprintf("external plug %d", s.Plug)
+	case RecordExternalPhysicalAddress:
+		return fmt.Sprintf("external %s", s.Addr)
+	}
+	return s.Type.String()
+}
+
+// RecordStatusInfo - the operand of Record Status
+type RecordStatusInfo byte
+
+// record status info as used on the bus
+const (
+	RecordingOwnSource          RecordStatusInfo = 0x01
+	RecordingDigitalService     RecordStatusInfo = 0x02
+	RecordingAnalogueService    RecordStatusInfo = 0x03
+	RecordingExternalInput      RecordStatusInfo = 0x04
+	NoRecordingDigitalService   RecordStatusInfo = 0x05
+	NoRecordingAnalogueService  RecordStatusInfo = 0x06
+	NoRecordingSelectService    RecordStatusInfo = 0x07
+	NoRecordingInvalidPlug      RecordStatusInfo = 0x09
+	NoRecordingInvalidAddress   RecordStatusInfo = 0x0A
+	NoRecordingCANotSupported   RecordStatusInfo = 0x0B
+	NoRecordingNoCAEntitlements RecordStatusInfo = 0x0C
+	NoRecordingCopyNotAllowed   RecordStatusInfo = 0x0D
+	NoRecordingNoFurtherCopies  RecordStatusInfo = 0x0E
+	NoRecordingNoMedia          RecordStatusInfo = 0x10
+	NoRecordingPlaying          RecordStatusInfo = 0x11
+	NoRecordingAlreadyRecording RecordStatusInfo = 0x12
+	NoRecordingMediaProtected   RecordStatusInfo = 0x13
+	NoRecordingNoSignal         RecordStatusInfo = 0x14
+	NoRecordingMediaProblem     RecordStatusInfo = 0x15
+	NoRecordingNoSpace          RecordStatusInfo = 0x16
+	NoRecordingParentalLock     RecordStatusInfo = 0x17
+	RecordingTerminated         RecordStatusInfo = 0x1A
+	RecordingAlreadyTerminated  RecordStatusInfo = 0x1B
+	NoRecordingOther            RecordStatusInfo = 0x1F
+)
+
+var recordStatusInfoNames = map[RecordStatusInfo]string{
+	RecordingOwnSource:          "recording own source",
+	RecordingDigitalService:     "recording digital service",
+	RecordingAnalogueService:    "recording analogue service",
+	RecordingExternalInput:      "recording external input",
+	NoRecordingDigitalService:   "unable to record digital service",
+	NoRecordingAnalogueService:  "unable to record analogue service",
+	NoRecordingSelectService:    "unable to select service",
+	NoRecordingInvalidPlug:      "invalid external plug",
+	NoRecordingInvalidAddress:   "invalid external physical address",
+	NoRecordingCANotSupported:   "CA system not supported",
+	NoRecordingNoCAEntitlements: "no CA entitlements",
+	NoRecordingCopyNotAllowed:   "copy not allowed",
+	NoRecordingNoFurtherCopies:  "no further copies allowed",
+	NoRecordingNoMedia:          "no media",
+	NoRecordingPlaying:          "playing",
+	NoRecordingAlreadyRecording: "already recording",
+	NoRecordingMediaProtected:   "media protected",
+	NoRecordingNoSignal:         "no source signal",
+	NoRecordingMediaProblem:     "media problem",
+	NoRecordingNoSpace:          "not enough space",
+	NoRecordingParentalLock:     "parental lock on",
+	RecordingTerminated:         "recording terminated",
+	RecordingAlreadyTerminated:  "recording already terminated",
+	NoRecordingOther:            "other reason"}
+
+// IsValid - whether the info is defined by the standard
+func (i RecordStatusInfo) IsValid() bool {
+	_, ok := recordStatusInfoNames[i]
+	return ok
+}
+
+// IsRecording - whether the recorder started recording
+func (i RecordStatusInfo) IsRecording() bool {
+	return i >= RecordingOwnSource && i <= RecordingExternalInput
+}
+
+func (i RecordStatusInfo) String() string {
+	if name, ok := recordStatusInfoNames[i]; ok {
+		return name
+	}
+	return "Unknown"
+}
+
+// RecordOn - start recording on a recorder, returns the Record Status
+// (check IsRecording, a recorder refusing to record replies with the
+// reason)
+func (c *Connection) RecordOn(ctx context.Context, address LogicalAddress, source RecordSource) (RecordStatusInfo, error) {
+	reply, err := c.Request(ctx, address, RecordOn{Source: source})
+	if err != nil {
+		return 0, err
+	}
+	status, ok := reply.(RecordStatus)
+	if !ok {
+		return 0, unexpectedReply(OpRecordOn, reply)
+	}
+	return status.Status, nil
+}
+
+// RecordOff - stop recording
+func (c *Connection) RecordOff(address LogicalAddress) error {
+	return c.Send(address, RecordOff{})
+}
+
+// OnRecordStatus - call fn for every Record Status received
+func (c *Connection) OnRecordStatus(fn func(source LogicalAddress, status RecordStatusInfo)) *Subscription {
+	return c.OnCommand(func(cmd Command) {
+		if m, ok := cmd.Message.(RecordStatus); ok {
+			fn(cmd.Initiator, m.Status)
+		}
+	}, OpRecordStatus)
+}
+
+// operand bytes of the record source types, including the type
+var recordSourceSizes = map[RecordSourceType]int{RecordOwnSource: 1,
+	RecordDigitalService: 8, RecordAnalogueService: 5, RecordExternalPlug: 2,
+	RecordExternalPhysicalAddress: 3}
+
+// recordSourceOperand - decode a record source (Record On)
+func recordSourceOperand(opcode Opcode, b []byte) (RecordSource, error) {
+	s := RecordSource{Type: RecordSourceType(b[0])}
+	size, ok := recordSourceSizes[s.Type]
+	if !ok {
+		return s, fmt.Errorf("%w: %s: invalid record source type %d", ErrInvalidFrame, opcode, b[0])
+	}
+	if err := needOperands(opcode, b, size); err != nil {
+		return s, err
+	}
+
+	switch s.Type {
+	case RecordDigitalService:
+		s.Digital = digitalServiceOperand(b[1:])
+	case RecordAnalogueService:
+		s.Analogue = analogueServiceOperand(b[1:])
+	case RecordExternalPlug:
+		s.Plug = b[1]
+	case RecordExternalPhysicalAddress:
+		s.Addr = physicalAddressOperand(b[1:])
+	}
+	return s, nil
+}
+
+func appendRecordSource(b []byte, s RecordSource) ([]byte, error) {
+	switch s.Type {
+	case RecordOwnSource:
+		return append(b, byte(s.Type)), nil
+	case RecordDigitalService:
+		return appendDigitalService(append(b, byte(s.Type)), s.Digital)
+	case RecordAnalogueService:
+		return appendAnalogueService(append(b, byte(s.Type)), s.Analogue)
+	case RecordExternalPlug:
+		if s.Plug == 0 {
+			return nil, fmt.Errorf("%w: invalid external plug 0", ErrInvalidFrame)
+		}
+		return append(b, byte(s.Type), s.Plug), nil
+	case RecordExternalPhysicalAddress:
+		return appendPhysicalAddress(append(b, byte(s.Type)), s.Addr), nil
+	}
+	return nil, fmt.Errorf("%w: invalid record source type %d", ErrInvalidFrame, s.Type)
+}
@@ -0,0 +1,429 @@
+package cec
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DefaultDeviceTTL - how long cached device state is used before it is
+// queried again
+const DefaultDeviceTTL = time.Minute
+
+// DeviceRegistry - cached state of the devices on the bus. The cache is
+// kept up to date from the commands on the bus (Report Physical Address,
+// Set OSD Name, Report Power Status, ...), fields older than the TTL are
+// queried again when a device is asked for. Changes are published as
+// DeviceAdded, DeviceRemoved and DeviceChanged events.
+type DeviceRegistry struct {
+	c   *Connection
+	ttl time.Duration
+
+	mu      sync.Mutex
+	devices map[LogicalAddress]*deviceEntry
+	scanned time.Time // last GetActiveDevices
+}
+
+type deviceEntry struct {
+	dev     Device
+	updated [numDeviceFields]time.Time
+}
+
+// deviceFields - the cached fields of a Device, how to read and how to
+// query them
+var deviceFields = [numDeviceFields]struct {
+	name  string
+	get   func(d *Device) interface{}
+	fetch func(ctx context.Context, c *Connection, address LogicalAddress) (func(*Device), error)
+}{
+	{"OSDName", func(d *Device) interface{} { return d.OSDName },
+		func(ctx context.Context, c *Connection, address LogicalAddress) (func(*Device), error) {
+			name, err := c.GetDeviceOSDNameContext(ctx, address)
+			return func(d *Device) { d.OSDName = name }, err
+		}},
+	{"Vendor", func(d *Device) interface{} { return d.Vendor },
+		func(ctx context.Context, c *Connection, address LogicalAddress) (func(*Device), error) {
+			id, err := c.GetDeviceVendorIDContext(ctx, address)
+			return func(d *Device) { d.Vendor, d.VendorID = GetVendorString(id), id }, err
+		}},
+	{"PowerStatus", func(d *Device) interface{} { return d.PowerStatus },
+		func(ctx context.Context, c *Connection, address LogicalAddress) (func(*Device), error) {
+			status, err := c.GetDevicePowerStatusContext(ctx, address)
+			return func(d *Device) { d.PowerStatus = status }, err
+		}},
+	{"PhysicalAddress", func(d *Device) interface{} { return d.PhysicalAddress },
+		func(ctx context.Context, c *Connection, address LogicalAddress) (func(*Device), error) {
+			physicalAddress, err := c.GetDevicePhysicalAddressContext(ctx, address)
+			return func(d *Device) { setPhysicalAddress(d, physicalAddress) }, err
+		}},
+	{"CECVersion", func(d *Device) interface{} { return d.CECVersion },
+		func(ctx context.Context, c *Connection, address LogicalAddress) (func(*Device), error) {
+			var version byte
+			if address == c.ownAddress() {
+				return func(d *Device) {}, nil
+			}
+			reply, err := c.requestOnce(ctx, address, GetCECVersion{})
+			if m, ok := reply.(CECVersion); ok {
+				version = m.Version
+			}
+			return func(d *Device) { d.CECVersion = version }, err
+		}},
+	{"MenuLanguage", func(d *Device) interface{} { return d.MenuLanguage },
+		func(ctx context.Context, c *Connection, address LogicalAddress) (func(*Device), error) {
+			// only the TV has a menu language
+			var language string
+			var err error
+			if address == TV && address != c.ownAddress() {
+				var reply Message
+				reply, err = c.requestOnce(ctx, address, GetMenuLanguage{})
+				if m, ok := reply.(SetMenuLanguage); ok {
+					language = m.Language
+				}
+			}
+			return func(d *Device) { d.MenuLanguage = language }, err
+		}},
+	{"ActiveSource", func(d *Device) interface{} { return d.ActiveSource },
+		func(ctx context.Context, c *Connection, address LogicalAddress) (func(*Device), error) {
+			active, err := c.IsActiveSourceContext(ctx, address)
+			return func(d *Device) { d.ActiveSource = active }, err
+		}},
+}
+
+// indexes of deviceFields
+const (
+	fieldOSDName = iota
+	fieldVendor
+	fieldPowerStatus
+	fieldPhysicalAddress
+	fieldCECVersion
+	fieldMenuLanguage
+	fieldActiveSource
+	numDeviceFields
+)
+
+func newDeviceRegistry(c *Connection, ttl time.Duration) *DeviceRegistry {
+	return &DeviceRegistry{c: c, ttl: ttl, devices: make(map[LogicalAddress]*deviceEntry)}
+}
+
+// Devices - the device cache of the connection
+func (c *Connection) Devices() *DeviceRegistry {
+	return c.registry
+}
+
+// Cached - the cached state of a device without querying the bus, false
+// if nothing is known about it
+func (r *DeviceRegistry) Cached(address LogicalAddress) (Device, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	e, ok := r.devices[address]
+	if !ok {
+		return Device{}, false
+	}
+	return e.dev, true
+}
+
+// Device - the state of a device, fields older than the TTL are queried.
+// A device that is not known yet is polled first, ErrNotAcknowledged is
+// returned if it is not there.
+func (r *DeviceRegistry) Device(ctx context.Context, address LogicalAddress) (Device, error) {
+	r.mu.Lock()
+	_, known := r.devices[address]
+	r.mu.Unlock()
+
+	if !known && address != r.c.ownAddress() {
+		present, err := r.c.PollDeviceContext(ctx, address)
+		if err != nil {
+			return Device{}, err
+		}
+		if !present {
+			return Device{}, fmt.Errorf("%w: no device at %s", ErrNotAcknowledged, address)
+		}
+	}
+
+	now := time.Now()
+	var events []interface{}
+	r.mu.Lock()
+	e := r.entry(address, &events)
+	var stale []int
+	for field := range deviceFields {
+		if e.updated[field].IsZero() || now.Sub(e.updated[field]) >= r.ttl {
+			stale = append(stale, field)
+		}
+	}
+	r.mu.Unlock()
+	r.publish(events)
+
+	for _, field := range stale {
+		set, err := deviceFields[field].fetch(ctx, r.c, address)
+		if err != nil && ctx.Err() != nil {
+			dev, _ := r.Cached(address)
+			return dev, err
+		}
+		if err != nil {
+			// devices that do not answer keep what is known about them
+			// and are not asked again until the TTL has passed
+			r.touch(address, field)
+			continue
+		}
+		r.update(address, field, set)
+	}
+	dev, _ := r.Cached(address)
+	return dev, nil
+}
+
+// List - the active devices (keyed like Connection.List). The set of
+// active devices is scanned again after the TTL.
+func (r *DeviceRegistry) List(ctx context.Context) (map[string]Device, error) {
+	devices := make(map[string]Device)
+
+	r.mu.Lock()
+	scan := r.scanned.IsZero() || time.Since(r.scanned) >= r.ttl
+	r.mu.Unlock()
+
+	if scan {
+		active, err := r.c.GetActiveDevicesContext(ctx)
+		if err != nil {
+			return devices, err
+		}
+		var events []interface{}
+		r.mu.Lock()
+		for address := range r.devices {
+			if !active[address] {
+				r.remove(address, &events)
+			}
+		}
+		for address, ok := range active {
+			if ok {
+				r.entry(LogicalAddress(address), &events)
+			}
+		}
+		r.scanned = time.Now()
+		r.mu.Unlock()
+		r.publish(events)
+	}
+
+	r.mu.Lock()
+	addresses := make([]LogicalAddress, 0, len(r.devices))
+	for address := range r.devices {
+		addresses = append(addresses, address)
+	}
+	r.mu.Unlock()
+
+	for _, address := range addresses {
+		dev, err := r.Device(ctx, address)
+		if err != nil {
+			if ctx.Err() != nil {
+				return devices, err
+			}
+			// gone since the scan
+			continue
+		}
+		devices[removeSeparators(dev.LogicalAddressName)] = dev
+	}
+	return devices, nil
+}
+
+// Invalidate - forget the cached state of a device, published as
+// DeviceRemoved. The device is added again when it is seen on the bus.
+func (r *DeviceRegistry) Invalidate(address LogicalAddress) {
+	var events []interface{}
+	r.mu.Lock()
+	r.remove(address, &events)
+	r.mu.Unlock()
+	r.publish(events)
+}
+
+// entry - the entry of a device, created if needed (r.mu held)
+func (r *DeviceRegistry) entry(address LogicalAddress, events *[]interface{}) *deviceEntry {
+	e, ok := r.devices[address]
+	if !ok {
+		e = &deviceEntry{dev: Device{
+			LogicalAddress:     address,
+			LogicalAddressName: address.String(),
+			PowerStatus:        PowerStatusUnknown,
+			PhysicalAddress:    InvalidPhysicalAddress,
+		}}
+		r.devices[address] = e
+		*events = append(*events, DeviceAdded{Device: e.dev, Timestamp: time.Now()})
+	}
+	return e
+}
+
+// remove - drop a device that is gone (r.mu held)
+func (r *DeviceRegistry) remove(address LogicalAddress, events *[]interface{}) {
+	e, ok := r.devices[address]
+	if !ok {
+		return
+	}
+	delete(r.devices, address)
+	*events = append(*events, DeviceRemoved{Device: e.dev, Timestamp: time.Now()})
+}
+
+// set - change a field of an entry (r.mu held)
+func (r *DeviceRegistry) set(e *deviceEntry, field int, set func(*Device), now time.Time, events *[]interface{}) {
+	old := deviceFields[field].get(&e.dev)
+	set(&e.dev)
+	e.updated[field] = now
+	if value := deviceFields[field].get(&e.dev); value != old {
+		*events = append(*events, DeviceChanged{Device: e.dev, Field: deviceFields[field].name,
+			Old: old, New: value, Timestamp: now})
+	}
+}
+
+func (r *DeviceRegistry) update(address LogicalAddress, field int, set func(*Device)) {
+	var events []interface{}
+	r.mu.Lock()
+	r.set(r.entry(address, &events), field, set, time.Now(), &events)
+	r.mu.Unlock()
+	r.publish(events)
+}
+
+// touch - mark a field as queried without changing it
+func (r *DeviceRegistry) touch(address LogicalAddress, field int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if e, ok := r.devices[address]; ok {
+		e.updated[field] = time.Now()
+	}
+}
+
+// seen - a device showed up on the bus
+func (r *DeviceRegistry) seen(address LogicalAddress) {
+	var events []interface{}
+	r.mu.Lock()
+	r.entry(address, &events)
+	r.mu.Unlock()
+	r.publish(events)
+}
+
+// publish - send the events collected under r.mu
+func (r *DeviceRegistry) publish(events []interface{}) {
+	for _, event := range events {
+		r.c.publish(event)
+	}
+}
+
+// observe - update the cache from an event received from the backend
+func (r *DeviceRegistry) observe(event interface{}) {
+	switch e := event.(type) {
+	case SourceActivated:
+		r.setActiveSource(e.Source, e.Active)
+	case Command:
+		if e.Initiator == Unregistered || e.Initiator == r.c.cachedAddress() {
+			return
+		}
+		r.observeMessage(e.Initiator, e.Message)
+	}
+}
+
+func (r *DeviceRegistry) observeMessage(initiator LogicalAddress, msg Message) {
+	switch m := msg.(type) {
+	case SetOSDName:
+		r.update(initiator, fieldOSDName, func(d *Device) { d.OSDName = m.Name })
+	case DeviceVendorID:
+		id := uint64(m.VendorID)
+		r.update(initiator, fieldVendor, func(d *Device) { d.Vendor, d.VendorID = GetVendorString(id), id })
+	case ReportPowerStatus:
+		r.update(initiator, fieldPowerStatus, func(d *Device) { d.PowerStatus = m.Status })
+	case ReportPhysicalAddress:
+		r.update(initiator, fieldPhysicalAddress, func(d *Device) { setPhysicalAddress(d, m.Addr) })
+	case CECVersion:
+		r.update(initiator, fieldCECVersion, func(d *Device) { d.CECVersion = m.Version })
+	case SetMenuLanguage:
+		r.update(initiator, fieldMenuLanguage, func(d *Device) { d.MenuLanguage = m.Language })
+	case ActiveSource:
+		r.setActiveSource(initiator, true)
+	case InactiveSource:
+		r.setActiveSource(initiator, false)
+	default:
+		// any message (or poll) shows the initiator is there
+		r.seen(initiator)
+	}
+}
+
+// setActiveSource - there is only one active source
+func (r *DeviceRegistry) setActiveSource(address LogicalAddress, active bool) {
+	var events []interface{}
+	r.mu.Lock()
+	now := time.Now()
+	if active {
+		for a, e := range r.devices {
+			if a != address && e.dev.ActiveSource {
+				r.set(e, fieldActiveSource, func(d *Device) { d.ActiveSource = false }, now, &events)
+			}
+		}
+	}
+	r.set(r.entry(address, &events), fieldActiveSource, func(d *Device) { d.ActiveSource = active }, now, &events)
+	r.mu.Unlock()
+	r.publish(events)
+}
+
+// sweep - poll every logical address, adding the devices that answer,
+// removing the ones that do not and refreshing stale fields
+func (r *DeviceRegistry) sweep(ctx context.Context) {
+	own := r.c.ownAddress()
+	for address := TV; address < Broadcast; address++ {
+		if address == own {
+			continue
+		}
+
+		present, err := r.c.PollDeviceContext(ctx, address)
+		if err != nil {
+			return
+		}
+
+		var events []interface{}
+		r.mu.Lock()
+		if present {
+			r.entry(address, &events)
+		} else {
+			r.remove(address, &events)
+		}
+		r.mu.Unlock()
+		r.publish(events)
+
+		if present {
+			r.Device(ctx, address)
+		}
+	}
+}
+
+// poll - sweep at the given interval until the connection is destroyed
+func (r *DeviceRegistry) poll(interval time.Duration, stopped chan struct{}) {
+	defer close(stopped)
+
+	// a sweep in progress is abandoned when the connection is destroyed
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-r.c.done:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		r.sweep(ctx)
+		select {
+		case <-ticker.C:
+		case <-r.c.done:
+			return
+		}
+	}
+}
+
+func setPhysicalAddress(d *Device, address PhysicalAddress) {
+	d.PhysicalAddress = address
+	d.RoomieName = ""
+	if address.IsValid() {
+		// the TV input the device is connected to
+		d.RoomieName = fmt.Sprintf("INPUT HDMI %x", address.nibble(0))
+	}
+}
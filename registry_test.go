@@ -0,0 +1,212 @@
+package cec_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/chbmuc/cec"
+	"github.com/chbmuc/cec/cectest"
+)
+
+// collector - collects the events of a subscription
+type collector struct {
+	mu     sync.Mutex
+	events []interface{}
+}
+
+func collect(t *testing.T, c *cec.Connection) *collector {
+	e := &collector{}
+	sub := c.Subscribe(func(event interface{}) {
+		e.mu.Lock()
+		e.events = append(e.events, event)
+		e.mu.Unlock()
+	})
+	t.Cleanup(sub.Unsubscribe)
+	return e
+}
+
+// wait - wait briefly for an event matching fn
+func (e *collector) wait(t *testing.T, what string, fn func(event interface{}) bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		e.mu.Lock()
+		for _, event := range e.events {
+			if fn(event) {
+				e.mu.Unlock()
+				return
+			}
+		}
+		e.mu.Unlock()
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("no %s event", what)
+}
+
+// guard - a backend that records calls to LogicalAddress from within the
+// event handler, which libcec does not allow
+type guard struct {
+	cec.Backend
+
+	mu        sync.Mutex
+	handling  bool
+	reentered bool
+}
+
+func (g *guard) SetEventHandler(handler func(event interface{})) {
+	g.Backend.SetEventHandler(func(event interface{}) {
+		g.mu.Lock()
+		g.handling = true
+		g.mu.Unlock()
+
+		handler(event)
+
+		g.mu.Lock()
+		g.handling = false
+		g.mu.Unlock()
+	})
+}
+
+func (g *guard) LogicalAddress() int {
+	g.mu.Lock()
+	if g.handling {
+		g.reentered = true
+	}
+	g.mu.Unlock()
+	return g.Backend.LogicalAddress()
+}
+
+func TestRegistryEvents(t *testing.T) {
+	bus := cectest.NewBus(cectest.NewTV())
+	g := &guard{Backend: bus.NewBackend(cectest.NewPlayback(4, 0x1000, "cec.go"))}
+	c, err := cec.OpenBackend(g)
+	if err != nil {
+		t.Fatalf("OpenBackend: %v", err)
+	}
+	defer c.Destroy()
+	events := collect(t, c)
+	// OpenBackend itself asks while events are delivered
+	g.mu.Lock()
+	g.reentered = false
+	g.mu.Unlock()
+
+	// Report Power Status from the TV
+	bus.Transmit([]byte{0x04, 0x90, 0x00})
+	events.wait(t, "DeviceAdded", func(event interface{}) bool {
+		e, ok := event.(cec.DeviceAdded)
+		return ok && e.Device.LogicalAddress == cec.TV
+	})
+	events.wait(t, "DeviceChanged", func(event interface{}) bool {
+		e, ok := event.(cec.DeviceChanged)
+		return ok && e.Field == "PowerStatus" && e.Old == cec.PowerStatusUnknown && e.New == cec.PowerStatusOn
+	})
+	if dev, ok := c.Devices().Cached(cec.TV); !ok || dev.PowerStatus != cec.PowerStatusOn {
+		t.Errorf("cached TV = %+v, %v, want powered on", dev, ok)
+	}
+
+	g.mu.Lock()
+	reentered := g.reentered
+	g.mu.Unlock()
+	if reentered {
+		t.Error("the backend's logical address was asked for while handling an event")
+	}
+
+	c.Devices().Invalidate(cec.TV)
+	events.wait(t, "DeviceRemoved", func(event interface{}) bool {
+		e, ok := event.(cec.DeviceRemoved)
+		return ok && e.Device.LogicalAddress == cec.TV && e.Device.PowerStatus == cec.PowerStatusOn
+	})
+	if _, ok := c.Devices().Cached(cec.TV); ok {
+		t.Error("TV still cached after Invalidate")
+	}
+}
+
+func TestRegistryNoAnswer(t *testing.T) {
+	tv := cectest.NewTV()
+	tv.MenuLanguage = "deu"
+	bus := cectest.NewBus(tv)
+	c, err := cec.OpenBackend(bus.NewBackend(cectest.NewPlayback(4, 0x1000, "cec.go")), cec.WithDeviceTTL(0))
+	if err != nil {
+		t.Fatalf("OpenBackend: %v", err)
+	}
+	defer c.Destroy()
+
+	dev, err := c.Devices().Device(context.Background(), cec.TV)
+	if err != nil || dev.MenuLanguage != "deu" || dev.RoomieName != "INPUT HDMI 0" {
+		t.Fatalf("Device = %+v, %v, want menu language deu and HDMI 0", dev, err)
+	}
+
+	// the TV stops answering Get Menu Language
+	bus.Update(0, func(d *cectest.Device) {
+		d.Handler = replying(0x91, nil)
+	})
+	events := collect(t, c)
+
+	dev, err = c.Devices().Device(context.Background(), cec.TV)
+	if err != nil || dev.MenuLanguage != "deu" {
+		t.Errorf("Device = %+v, %v, want the cached menu language", dev, err)
+	}
+
+	// events are delivered in order, everything published before is there
+	// once the TV reports being on
+	bus.Transmit([]byte{0x04, 0x90, 0x00})
+	events.wait(t, "DeviceChanged", func(event interface{}) bool {
+		e, ok := event.(cec.DeviceChanged)
+		return ok && e.Field == "PowerStatus"
+	})
+	events.mu.Lock()
+	defer events.mu.Unlock()
+	for _, event := range events.events {
+		if e, ok := event.(cec.DeviceChanged); ok && e.Field == "MenuLanguage" {
+			t.Errorf("unanswered request published %+v", e)
+		}
+	}
+}
+
+// sluggish - a backend taking its time to answer queries about a device
+type sluggish struct {
+	cec.Backend
+}
+
+const sluggishDelay = 200 * time.Millisecond
+
+func (s sluggish) GetDeviceOSDName(address int) string {
+	time.Sleep(sluggishDelay)
+	return s.Backend.GetDeviceOSDName(address)
+}
+
+func (s sluggish) GetDeviceVendorID(address int) uint64 {
+	time.Sleep(sluggishDelay)
+	return s.Backend.GetDeviceVendorID(address)
+}
+
+func (s sluggish) GetDevicePowerStatus(address int) int {
+	time.Sleep(sluggishDelay)
+	return s.Backend.GetDevicePowerStatus(address)
+}
+
+func (s sluggish) GetDevicePhysicalAddress(address int) uint16 {
+	time.Sleep(sluggishDelay)
+	return s.Backend.GetDevicePhysicalAddress(address)
+}
+
+func TestRegistryPollingDestroy(t *testing.T) {
+	bus := cectest.NewBus(cectest.NewTV())
+	c, err := cec.OpenBackend(sluggish{bus.NewBackend(cectest.NewPlayback(4, 0x1000, "cec.go"))},
+		cec.WithDevicePolling(time.Hour))
+	if err != nil {
+		t.Fatalf("OpenBackend: %v", err)
+	}
+	// let the first sweep reach the TV
+	time.Sleep(50 * time.Millisecond)
+
+	// only the query in progress is waited for, not the rest of the sweep
+	start := time.Now()
+	c.Destroy()
+	if elapsed := time.Since(start); elapsed > 2*sluggishDelay {
+		t.Errorf("Destroy waited %v for the polling", elapsed)
+	}
+}
@@ -0,0 +1,135 @@
+package cec
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// how long a device has to answer a request (CEC "required maximum
+// response time")
+const replyTimeout = time.Second
+
+// how often a request is sent when the context has no deadline
+const defaultRequestAttempts = 3
+
+// replyOpcodes - the replies that answer a request
+var replyOpcodes = map[Opcode][]Opcode{
+	OpGiveDevicePowerStatus:       {OpReportPowerStatus},
+	OpGiveOSDName:                 {OpSetOSDName},
+	OpGivePhysicalAddress:         {OpReportPhysicalAddress},
+	OpGiveDeviceVendorID:          {OpDeviceVendorID},
+	OpGetCECVersion:               {OpCECVersion},
+	OpGetMenuLanguage:             {OpSetMenuLanguage},
+	OpGiveAudioStatus:             {OpReportAudioStatus},
+	OpGiveSystemAudioModeStatus:   {OpSystemAudioModeStatus},
+	OpSystemAudioModeRequest:      {OpSetSystemAudioMode},
+	OpGiveDeckStatus:              {OpDeckStatus},
+	OpGiveTunerDeviceStatus:       {OpTunerDeviceStatus},
+	OpMenuRequest:                 {OpMenuStatus},
+	OpGiveFeatures:                {OpReportFeatures},
+	OpRequestCurrentLatency:       {OpReportCurrentLatency},
+	OpRequestShortAudioDescriptor: {OpReportShortAudioDescriptor},
+	OpRequestARCInitiation:        {OpInitiateARC},
+	OpRequestARCTermination:       {OpTerminateARC},
+	OpInitiateARC:                 {OpReportARCInitiated, OpReportARCTerminated},
+	OpTerminateARC:                {OpReportARCTerminated},
+	OpRecordOn:                    {OpRecordStatus},
+	OpRecordTVScreen:              {OpRecordOn},
+	OpSetAnalogueTimer:            {OpTimerStatus},
+	OpSetDigitalTimer:             {OpTimerStatus},
+	OpSetExternalTimer:            {OpTimerStatus},
+	OpClearAnalogueTimer:          {OpTimerClearedStatus},
+	OpClearDigitalTimer:           {OpTimerClearedStatus},
+	OpClearExternalTimer:          {OpTimerClearedStatus},
+	OpAbort:                       {},
+}
+
+// Request - send a message to the device with the given logical address
+// and wait for its reply (e.g. ReportPowerStatus for GiveDevicePowerStatus).
+// A Feature Abort for the request is returned as *FeatureAbortError, a
+// reply whose operands do not decode as ErrInvalidFrame. The message is
+// sent again every second without a reply until the context is done (or
+// three times if the context has no deadline).
+func (c *Connection) Request(ctx context.Context, destination LogicalAddress, msg Message) (Message, error) {
+	if msg == nil {
+		return nil, fmt.Errorf("%w: no message given", ErrInvalidFrame)
+	}
+	if !destination.IsValid() || destination == Broadcast {
+		return nil, fmt.Errorf("%w: invalid logical address", ErrInvalidFrame)
+	}
+	opcode := msg.Opcode()
+	replies, ok := replyOpcodes[opcode]
+	if !ok {
+		return nil, fmt.Errorf("%s: no reply defined", opcode)
+	}
+
+	received := make(chan Message, 1)
+	sub := c.OnCommandFilter(CommandFilter{Initiators: []LogicalAddress{destination}}, func(cmd Command) {
+		if cmd.Message == nil {
+			return
+		}
+		abort, isAbort := cmd.Message.(FeatureAbort)
+		if !(isAbort && abort.Feature == opcode) && !containsOpcode(replies, cmd.Message.Opcode()) {
+			return
+		}
+		select {
+		case received <- cmd.Message:
+		default:
+		}
+	})
+	defer sub.Unsubscribe()
+
+	_, hasDeadline := ctx.Deadline()
+	timer := time.NewTimer(replyTimeout)
+	defer timer.Stop()
+
+	for attempt := 1; ; attempt++ {
+		if err := c.Send(destination, msg); err != nil {
+			return nil, err
+		}
+
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(replyTimeout)
+
+		select {
+		case reply := <-received:
+			if abort, ok := reply.(FeatureAbort); ok {
+				return nil, &FeatureAbortError{Opcode: abort.Feature, Reason: abort.Reason}
+			}
+			if raw, ok := reply.(RawMessage); ok {
+				// the operands did not decode, see decodeCommand
+				return nil, fmt.Errorf("%w: %s: malformed reply % x from %s", ErrInvalidFrame, raw.Code, raw.Operands, destination)
+			}
+			return reply, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-c.done:
+			return nil, fmt.Errorf("%w: connection destroyed", ErrAdapterLost)
+		case <-timer.C:
+			if !hasDeadline && attempt >= defaultRequestAttempts {
+				return nil, fmt.Errorf("%w: %s: no reply from %s", ErrTimeout, opcode, destination)
+			}
+		}
+	}
+}
+
+// unexpectedReply - the error for a reply of another type than the caller
+// of Request expects
+func unexpectedReply(request Opcode, reply Message) error {
+	return fmt.Errorf("%w: %s: unexpected reply %s", ErrInvalidFrame, request, reply.Opcode())
+}
+
+// requestOnce - send a request without sending it again, for devices that
+// may never answer
+func (c *Connection) requestOnce(ctx context.Context, destination LogicalAddress, msg Message) (Message, error) {
+	ctx, cancel := context.WithTimeout(ctx, replyTimeout)
+	defer cancel()
+
+	return c.Request(ctx, destination, msg)
+}
@@ -0,0 +1,87 @@
+package cec_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/chbmuc/cec"
+	"github.com/chbmuc/cec/cectest"
+)
+
+// openBus - a connection for a playback device at 4 (1.0.0.0) on a bus
+// with the given devices
+func openBus(t *testing.T, devices ...*cectest.Device) (*cec.Connection, *cectest.Bus) {
+	t.Helper()
+
+	bus := cectest.NewBus(devices...)
+	c, err := cec.OpenBackend(bus.NewBackend(cectest.NewPlayback(4, 0x1000, "cec.go")))
+	if err != nil {
+		t.Fatalf("OpenBackend: %v", err)
+	}
+	t.Cleanup(c.Destroy)
+	return c, bus
+}
+
+// replying - a handler answering opcode with the given frame
+func replying(opcode byte, reply []byte) func(d *cectest.Device, frame []byte) ([][]byte, bool) {
+	return func(d *cectest.Device, frame []byte) ([][]byte, bool) {
+		if len(frame) < 2 || frame[1] != opcode {
+			return nil, false
+		}
+		if reply == nil {
+			return nil, true
+		}
+		return [][]byte{reply}, true
+	}
+}
+
+func TestRequest(t *testing.T) {
+	tv := cectest.NewTV()
+	c, bus := openBus(t, tv)
+
+	reply, err := c.Request(context.Background(), cec.TV, cec.GiveDevicePowerStatus{})
+	if err != nil {
+		t.Fatalf("Request: %v", err)
+	}
+	if status, ok := reply.(cec.ReportPowerStatus); !ok || status.Status != cec.PowerStatusStandby {
+		t.Errorf("reply = %#v, want standby", reply)
+	}
+
+	// Feature Abort for the request: refused
+	bus.Update(0, func(d *cectest.Device) { d.Handler = replying(0x8F, []byte{0x04, 0x00, 0x8F, 0x04}) })
+	_, err = c.Request(context.Background(), cec.TV, cec.GiveDevicePowerStatus{})
+	var abort *cec.FeatureAbortError
+	if !errors.As(err, &abort) || abort.Opcode != cec.OpGiveDevicePowerStatus || abort.Reason != 4 {
+		t.Errorf("Request = %v, want a FeatureAbortError", err)
+	}
+
+	// a reply without the power status operand
+	bus.Update(0, func(d *cectest.Device) { d.Handler = replying(0x8F, []byte{0x04, 0x90}) })
+	if reply, err := c.Request(context.Background(), cec.TV, cec.GiveDevicePowerStatus{}); !errors.Is(err, cec.ErrInvalidFrame) {
+		t.Errorf("Request = %#v, %v, want ErrInvalidFrame", reply, err)
+	}
+
+	// no reply at all
+	bus.Update(0, func(d *cectest.Device) { d.Handler = replying(0x8F, nil) })
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := c.Request(ctx, cec.TV, cec.GiveDevicePowerStatus{}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Request = %v, want the context's deadline", err)
+	}
+}
+
+func TestRequestInvalid(t *testing.T) {
+	c, _ := openBus(t, cectest.NewTV())
+
+	if _, err := c.Request(context.Background(), cec.Broadcast, cec.GiveOSDName{}); !errors.Is(err, cec.ErrInvalidFrame) {
+		t.Errorf("Request to broadcast = %v, want ErrInvalidFrame", err)
+	}
+	if _, err := c.Request(context.Background(), cec.TV, nil); !errors.Is(err, cec.ErrInvalidFrame) {
+		t.Errorf("Request without message = %v, want ErrInvalidFrame", err)
+	}
+	if _, err := c.Request(context.Background(), cec.TV, cec.Standby{}); err == nil {
+		t.Error("Request for a message without reply succeeded")
+	}
+}
@@ -0,0 +1,196 @@
+package cec
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// how long SwitchTo waits for the device to become the active source when
+// the context has no deadline, devices in standby need a few seconds
+const switchTimeout = 10 * time.Second
+
+// SetStreamPath - ask the device at the given physical address to become
+// the active source, switches on the way switch to it
+func (c *Connection) SetStreamPath(address PhysicalAddress) error {
+	if !address.IsValid() {
+		return fmt.Errorf("cec: invalid physical address %s", address)
+	}
+	return c.Send(Broadcast, SetStreamPath{Addr: address})
+}
+
+// RoutingChange - announce that the input of a switch (or the TV) changed
+// from one physical address to another
+func (c *Connection) RoutingChange(from, to PhysicalAddress) error {
+	return c.Send(Broadcast, RoutingChange{From: from, To: to})
+}
+
+// RoutingInformation - announce the active route below a switch
+func (c *Connection) RoutingInformation(address PhysicalAddress) error {
+	return c.Send(Broadcast, RoutingInformation{Addr: address})
+}
+
+// OnRouting - call fn for every Routing Change (from is the old route)
+// and Routing Information (from is InvalidPhysicalAddress) received
+func (c *Connection) OnRouting(fn func(source LogicalAddress, from, to PhysicalAddress)) *Subscription {
+	return c.OnCommand(func(cmd Command) {
+		switch m := cmd.Message.(type) {
+		case RoutingChange:
+			fn(cmd.Initiator, m.From, m.To)
+		case RoutingInformation:
+			fn(cmd.Initiator, InvalidPhysicalAddress, m.Addr)
+		}
+	}, OpRoutingChange, OpRoutingInformation)
+}
+
+// RequestActiveSource - ask the active source to announce itself, returns
+// its logical and physical address. Without an active source this fails
+// with ErrTimeout.
+func (c *Connection) RequestActiveSource(ctx context.Context) (LogicalAddress, PhysicalAddress, error) {
+	source, address, ok, err := c.awaitActiveSource(ctx, RequestActiveSource{}, replyTimeout,
+		func(LogicalAddress, PhysicalAddress) bool { return true })
+	if err == nil && !ok {
+		err = fmt.Errorf("%w: no active source", ErrTimeout)
+	}
+	return source, address, err
+}
+
+// SwitchTo - make a device the active source. The target is a
+// LogicalAddress, a PhysicalAddress or a string with the OSD name of a
+// device ("PlayStation 5"), a physical address ("1.0.0.0") or the name of a
+// logical address ("Playback 1"). The TV is woken up first if it is not
+// on, the switch is confirmed by the Active Source of the device (or a
+// device behind it). Switching to the connection's own device announces it
+// as the active source.
+func (c *Connection) SwitchTo(ctx context.Context, target interface{}) error {
+	address, err := c.switchTarget(ctx, target)
+	if err != nil {
+		return err
+	}
+	if err := c.wakeTV(ctx); err != nil {
+		return err
+	}
+	if address == c.ownPhysicalAddress() {
+		// the connection does not receive its own Active Source, announce
+		// it instead of waiting for it
+		return c.SendContext(ctx, Broadcast, ActiveSource{Addr: address})
+	}
+
+	_, _, ok, err := c.awaitActiveSource(ctx, SetStreamPath{Addr: address}, switchTimeout,
+		func(_ LogicalAddress, a PhysicalAddress) bool { return a == address || a.IsChildOf(address) })
+	if err == nil && !ok {
+		err = fmt.Errorf("%w: %s did not become the active source", ErrTimeout, address)
+	}
+	return err
+}
+
+// switchTarget - the physical address of a SwitchTo target
+func (c *Connection) switchTarget(ctx context.Context, target interface{}) (PhysicalAddress, error) {
+	switch target := target.(type) {
+	case PhysicalAddress:
+		if !target.IsValid() {
+			return InvalidPhysicalAddress, fmt.Errorf("cec: invalid physical address %s", target)
+		}
+		return target, nil
+	case LogicalAddress:
+		if !target.IsValid() || target == Broadcast {
+			return InvalidPhysicalAddress, fmt.Errorf("cec: invalid logical address %d", target)
+		}
+		dev, err := c.registry.Device(ctx, target)
+		if err != nil {
+			return InvalidPhysicalAddress, err
+		}
+		if !dev.PhysicalAddress.IsValid() {
+			return InvalidPhysicalAddress, fmt.Errorf("cec: %s has no valid physical address", target)
+		}
+		return dev.PhysicalAddress, nil
+	case string:
+		if address, err := ParsePhysicalAddress(target); err == nil {
+			return c.switchTarget(ctx, address)
+		}
+		devices, err := c.registry.List(ctx)
+		if err != nil {
+			return InvalidPhysicalAddress, err
+		}
+		found := Unregistered
+		for _, dev := range devices {
+			if strings.EqualFold(dev.OSDName, target) && dev.LogicalAddress < found {
+				found = dev.LogicalAddress
+			}
+		}
+		if found != Unregistered {
+			return c.switchTarget(ctx, found)
+		}
+		if address, err := ParseLogicalAddress(target); err == nil {
+			return c.switchTarget(ctx, address)
+		}
+		return InvalidPhysicalAddress, fmt.Errorf("cec: no device %q", target)
+	}
+	return InvalidPhysicalAddress, fmt.Errorf("cec: invalid target type %T", target)
+}
+
+// wakeTV - send Image View On to the TV unless it is on (or there is none)
+func (c *Connection) wakeTV(ctx context.Context) error {
+	if c.ownAddress() == TV {
+		return nil
+	}
+	dev, err := c.registry.Device(ctx, TV)
+	if errors.Is(err, ErrNotAcknowledged) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if dev.PowerStatus == PowerStatusOn {
+		return nil
+	}
+	return c.SendContext(ctx, TV, ImageViewOn{})
+}
+
+// awaitActiveSource - broadcast msg and wait for an Active Source passing
+// match, ok is false if none was received within the timeout (only used
+// if the context has no deadline)
+func (c *Connection) awaitActiveSource(ctx context.Context, msg Message, timeout time.Duration,
+	match func(source LogicalAddress, address PhysicalAddress) bool) (LogicalAddress, PhysicalAddress, bool, error) {
+	received := make(chan Command, 1)
+	sub := c.OnCommand(func(cmd Command) {
+		m, ok := cmd.Message.(ActiveSource)
+		if !ok || !match(cmd.Initiator, m.Addr) {
+			return
+		}
+		select {
+		case received <- cmd:
+		default:
+		}
+	}, OpActiveSource)
+	defer sub.Unsubscribe()
+
+	if err := c.SendContext(ctx, Broadcast, msg); err != nil {
+		return Unregistered, InvalidPhysicalAddress, false, err
+	}
+
+	var expired <-chan time.Time
+	if _, hasDeadline := ctx.Deadline(); !hasDeadline {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	select {
+	case cmd := <-received:
+		return cmd.Initiator, cmd.Message.(ActiveSource).Addr, true, nil
+	case <-ctx.Done():
+		return Unregistered, InvalidPhysicalAddress, false, ctx.Err()
+	case <-c.done:
+		return Unregistered, InvalidPhysicalAddress, false, fmt.Errorf("%w: connection destroyed", ErrAdapterLost)
+	case <-expired:
+		return Unregistered, InvalidPhysicalAddress, false, nil
+	}
+}
+
+// ownPhysicalAddress - the physical address of the connection
+func (c *Connection) ownPhysicalAddress() PhysicalAddress {
+	return c.GetDevicePhysicalAddress(c.ownAddress())
+}
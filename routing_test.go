@@ -0,0 +1,83 @@
+package cec_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/chbmuc/cec"
+	"github.com/chbmuc/cec/cectest"
+)
+
+func TestSwitchTo(t *testing.T) {
+	tests := []struct {
+		name   string
+		target interface{}
+		want   int // active source afterwards
+	}{
+		{name: "logical address", target: cec.Playback2, want: 8},
+		{name: "physical address", target: cec.PhysicalAddress(0x2000), want: 8},
+		{name: "OSD name", target: "player", want: 8},
+		{name: "logical address name", target: "Playback 2", want: 8},
+		{name: "own device", target: cec.PhysicalAddress(0x1000), want: 4},
+		{name: "own logical address", target: cec.Playback1, want: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, bus := openBus(t, cectest.NewTV(), cectest.NewPlayback(8, 0x2000, "Player"))
+			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+			defer cancel()
+
+			if err := c.SwitchTo(ctx, tt.target); err != nil {
+				t.Fatalf("SwitchTo(%v): %v", tt.target, err)
+			}
+			if got := bus.ActiveSource(); got != tt.want {
+				t.Errorf("active source = %d, want %d", got, tt.want)
+			}
+			// the TV was in standby
+			if got := bus.Device(0).PowerStatus; got != cectest.PowerOn {
+				t.Errorf("TV power status = %d, want on", got)
+			}
+		})
+	}
+}
+
+func TestSwitchToOwnDevice(t *testing.T) {
+	c, bus := openBus(t, cectest.NewTV())
+
+	// without a deadline SwitchTo would wait 10 seconds for the Active
+	// Source it sends itself
+	start := time.Now()
+	if err := c.SwitchTo(context.Background(), "1.0.0.0"); err != nil {
+		t.Fatalf("SwitchTo: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("SwitchTo took %v", elapsed)
+	}
+
+	announced := false
+	for _, f := range bus.Frames() {
+		if bytes.Equal(f, []byte{0x4F, 0x82, 0x10, 0x00}) {
+			announced = true
+		}
+		if len(f) > 1 && f[1] == 0x86 {
+			t.Errorf("sent Set Stream Path % x to the own device", f)
+		}
+	}
+	if !announced {
+		t.Errorf("no Active Source for 1.0.0.0 in % x", bus.Frames())
+	}
+}
+
+func TestSwitchToUnknown(t *testing.T) {
+	c, _ := openBus(t, cectest.NewTV())
+
+	if err := c.SwitchTo(context.Background(), "nothing"); err == nil {
+		t.Error("SwitchTo to a device that does not exist succeeded")
+	}
+	if err := c.SwitchTo(context.Background(), 42); err == nil {
+		t.Error("SwitchTo with an int succeeded")
+	}
+}
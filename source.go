@@ -0,0 +1,102 @@
+package cec
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// how long OneTouchPlay waits for the TV to be on when the context has no
+// deadline, and how often it asks for the power status meanwhile
+const (
+	powerOnTimeout      = 10 * time.Second
+	powerOnPollInterval = 500 * time.Millisecond
+)
+
+// SetActiveSource - announce the connection as the active source (Active
+// Source with its physical address), the TV switches to its input
+func (c *Connection) SetActiveSource() error {
+	return c.Send(Broadcast, ActiveSource{Addr: c.ownPhysicalAddress()})
+}
+
+// SetInactiveView - tell the TV that the connection is no longer the
+// active source, the TV may switch to another input or its tuner
+func (c *Connection) SetInactiveView() error {
+	return c.Send(TV, InactiveSource{Addr: c.ownPhysicalAddress()})
+}
+
+// ImageViewOn - turn the TV on and show the active source
+func (c *Connection) ImageViewOn() error {
+	return c.Send(TV, ImageViewOn{})
+}
+
+// TextViewOn - like ImageViewOn, also removes menus from the screen
+func (c *Connection) TextViewOn() error {
+	return c.Send(TV, TextViewOn{})
+}
+
+// the deck status after a deck control mode
+var deckControlInfo = map[DeckControlMode]DeckInfo{DeckControlSkipForward: DeckInfoSkipForward,
+	DeckControlSkipReverse: DeckInfoSkipReverse, DeckControlStop: DeckInfoStop,
+	DeckControlEject: DeckInfoNoMedia}
+
+// SetDeckControlMode - report the deck of the connection as skipping,
+// stopped or ejected to the TV (Deck Status), e.g. after a Deck Control
+// was handled
+func (c *Connection) SetDeckControlMode(mode DeckControlMode) error {
+	info, ok := deckControlInfo[mode]
+	if !ok {
+		return fmt.Errorf("cec: invalid deck control mode %d", mode)
+	}
+	return c.SetDeckInfo(info)
+}
+
+// SetDeckInfo - report the deck status of the connection (playing,
+// stopped, ...) to the TV
+func (c *Connection) SetDeckInfo(info DeckInfo) error {
+	return c.Send(TV, DeckStatus{Info: info})
+}
+
+// OneTouchPlay - turn the TV on (PowerOn, which sends Image View On), make
+// the connection the active source and wait until the TV reports that it
+// is on
+func (c *Connection) OneTouchPlay(ctx context.Context) error {
+	if err := c.PowerOnContext(ctx, TV); err != nil {
+		return err
+	}
+	if err := c.SendContext(ctx, Broadcast, ActiveSource{Addr: c.ownPhysicalAddress()}); err != nil {
+		return err
+	}
+
+	var expired <-chan time.Time
+	if _, hasDeadline := ctx.Deadline(); !hasDeadline {
+		timer := time.NewTimer(powerOnTimeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	for {
+		reply, err := c.requestOnce(ctx, TV, GiveDevicePowerStatus{})
+		status, ok := reply.(ReportPowerStatus)
+		switch {
+		case err == nil && ok && status.Status == PowerStatusOn:
+			return nil
+		case errors.Is(err, ErrNotAcknowledged), errors.Is(err, ErrAdapterLost):
+			return err
+		case ctx.Err() != nil:
+			return ctx.Err()
+		}
+		// no answer (or a malformed one) while the TV starts, or not on yet
+
+		select {
+		case <-time.After(powerOnPollInterval):
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-c.done:
+			return fmt.Errorf("%w: connection destroyed", ErrAdapterLost)
+		case <-expired:
+			return fmt.Errorf("%w: the TV did not turn on", ErrTimeout)
+		}
+	}
+}
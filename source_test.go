@@ -0,0 +1,50 @@
+package cec_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/chbmuc/cec/cectest"
+)
+
+func TestOneTouchPlay(t *testing.T) {
+	c, bus := openBus(t, cectest.NewTV())
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := c.OneTouchPlay(ctx); err != nil {
+		t.Fatalf("OneTouchPlay: %v", err)
+	}
+	if got := bus.Device(0).PowerStatus; got != cectest.PowerOn {
+		t.Errorf("TV power status = %d, want on", got)
+	}
+	if got := bus.ActiveSource(); got != 4 {
+		t.Errorf("active source = %d, want 4", got)
+	}
+}
+
+func TestOneTouchPlayMalformedReply(t *testing.T) {
+	tv := cectest.NewTV()
+	// the first answers to Give Device Power Status lack the status
+	malformed := 2
+	tv.Handler = func(d *cectest.Device, frame []byte) ([][]byte, bool) {
+		if len(frame) < 2 || frame[1] != 0x8F || malformed == 0 {
+			return nil, false
+		}
+		malformed--
+		return [][]byte{{0x04, 0x90}}, true
+	}
+	c, bus := openBus(t, tv)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := c.OneTouchPlay(ctx); err != nil {
+		t.Fatalf("OneTouchPlay: %v", err)
+	}
+	bus.Update(0, func(d *cectest.Device) {
+		if malformed != 0 {
+			t.Errorf("%d malformed replies left, OneTouchPlay stopped polling early", malformed)
+		}
+	})
+}
@@ -0,0 +1,284 @@
+package cec
+
+// Subscription - a function receiving the events of a connection that
+// pass its filter. Each subscription has its own buffer (see
+// WithEventBuffer and WithOverflowPolicy) and goroutine, so a slow
+// subscriber does not hold up the others.
+type Subscription struct {
+	c       *Connection
+	filter  func(event interface{}) bool
+	fn      func(event interface{})
+	buffer  *eventBuffer
+	stopped chan struct{}
+}
+
+// CommandFilter - selects commands by opcode, initiator and destination
+// logical address. Empty fields match everything.
+type CommandFilter struct {
+	Opcodes      []Opcode
+	Initiators   []LogicalAddress
+	Destinations []LogicalAddress
+}
+
+// Match - check if the command passes the filter
+func (f CommandFilter) Match(cmd Command) bool {
+	if len(f.Opcodes) > 0 {
+		if !cmd.OpcodeSet || !containsOpcode(f.Opcodes, cmd.Opcode) {
+			return false
+		}
+	}
+	if len(f.Initiators) > 0 && !containsAddress(f.Initiators, cmd.Initiator) {
+		return false
+	}
+	if len(f.Destinations) > 0 && !containsAddress(f.Destinations, cmd.Destination) {
+		return false
+	}
+	return true
+}
+
+// log levels from most to least severe
+var logLevels = map[string]int{"ERROR": 1, "WARNING": 2, "NOTICE": 3,
+	"TRAFFIC": 4, "DEBUG": 5, "ALL": 6}
+
+// Subscribe - call fn for every event
+func (c *Connection) Subscribe(fn func(event interface{})) *Subscription {
+	return c.subscribe(nil, fn)
+}
+
+// OnKeyPress - call fn for every key press and release
+func (c *Connection) OnKeyPress(fn func(KeyPress)) *Subscription {
+	return c.subscribe(func(event interface{}) bool {
+		_, ok := event.(KeyPress)
+		return ok
+	}, func(event interface{}) {
+		fn(event.(KeyPress))
+	})
+}
+
+// OnCommand - call fn for received commands with one of the given opcodes
+// (all commands if none are given)
+func (c *Connection) OnCommand(fn func(Command), opcodes ...Opcode) *Subscription {
+	return c.OnCommandFilter(CommandFilter{Opcodes: opcodes}, fn)
+}
+
+// OnCommandFilter - call fn for received commands passing the filter
+func (c *Connection) OnCommandFilter(filter CommandFilter, fn func(Command)) *Subscription {
+	return c.subscribe(func(event interface{}) bool {
+		cmd, ok := event.(Command)
+		return ok && filter.Match(cmd)
+	}, func(event interface{}) {
+		fn(event.(Command))
+	})
+}
+
+// OnSourceActivated - call fn when a local source is (de)activated
+func (c *Connection) OnSourceActivated(fn func(SourceActivated)) *Subscription {
+	return c.subscribe(func(event interface{}) bool {
+		_, ok := event.(SourceActivated)
+		return ok
+	}, func(event interface{}) {
+		fn(event.(SourceActivated))
+	})
+}
+
+// OnAlert - call fn for every alert
+func (c *Connection) OnAlert(fn func(Alert)) *Subscription {
+	return c.subscribe(func(event interface{}) bool {
+		_, ok := event.(Alert)
+		return ok
+	}, func(event interface{}) {
+		fn(event.(Alert))
+	})
+}
+
+// OnMenuState - call fn when the menu is (de)activated
+func (c *Connection) OnMenuState(fn func(MenuState)) *Subscription {
+	return c.subscribe(func(event interface{}) bool {
+		_, ok := event.(MenuState)
+		return ok
+	}, func(event interface{}) {
+		fn(event.(MenuState))
+	})
+}
+
+// OnLog - call fn for log messages of at least the given level ("ERROR",
+// "WARNING", "NOTICE", "TRAFFIC", "DEBUG" or "ALL"), e.g. "WARNING" gets
+// errors and warnings
+func (c *Connection) OnLog(minLevel string, fn func(LogMessage)) *Subscription {
+	max, ok := logLevels[minLevel]
+	if !ok {
+		max = logLevels["ALL"]
+	}
+	return c.subscribe(func(event interface{}) bool {
+		msg, ok := event.(LogMessage)
+		if !ok {
+			return false
+		}
+		level, ok := logLevels[msg.Level]
+		return !ok || level <= max
+	}, func(event interface{}) {
+		fn(event.(LogMessage))
+	})
+}
+
+func (c *Connection) subscribe(filter func(event interface{}) bool, fn func(event interface{})) *Subscription {
+	s := c.newSubscription(filter, fn)
+
+	c.mu.Lock()
+	c.attach(s)
+	c.mu.Unlock()
+
+	go s.run()
+	return s
+}
+
+func (c *Connection) newSubscription(filter func(event interface{}) bool, fn func(event interface{})) *Subscription {
+	return &Subscription{
+		c:       c,
+		filter:  filter,
+		fn:      fn,
+		buffer:  newEventBuffer(c.options.eventBufferSize, c.options.overflowPolicy),
+		stopped: make(chan struct{}),
+	}
+}
+
+// attach - add a subscription, on a destroyed connection it is closed
+// right away (c.mu held)
+func (c *Connection) attach(s *Subscription) {
+	if c.closed {
+		s.buffer.close()
+		return
+	}
+	c.subs = append(c.subs, s)
+}
+
+// publish - event handler given to the backend, hands the event to every
+// matching subscription
+func (c *Connection) publish(event interface{}) {
+	if e, ok := event.(SourceActivated); ok {
+		// the source is the connection's own device
+		c.setOwnAddress(e.Source)
+	}
+	c.registry.observe(event)
+	c.observeAudioModes(event)
+	event = c.decodeVendor(event)
+
+	c.mu.Lock()
+	subs := c.subs
+	c.mu.Unlock()
+
+	for _, s := range subs {
+		if s.filter == nil || s.filter(event) {
+			s.buffer.push(event)
+		}
+	}
+}
+
+func (s *Subscription) run() {
+	defer close(s.stopped)
+
+	for {
+		event, ok := s.buffer.pop()
+		if !ok {
+			return
+		}
+		s.fn(event)
+	}
+}
+
+// Unsubscribe - stop delivering events, events still buffered are
+// dropped. Can be called from within the subscriber's function.
+func (s *Subscription) Unsubscribe() {
+	c := s.c
+
+	c.mu.Lock()
+	for i, sub := range c.subs {
+		if sub == s {
+			// copy, publish may still be using the old slice
+			subs := make([]*Subscription, 0, len(c.subs)-1)
+			subs = append(subs, c.subs[:i]...)
+			c.subs = append(subs, c.subs[i+1:]...)
+			_, dropped := s.buffer.stats()
+			c.dropped += dropped
+			break
+		}
+	}
+	c.mu.Unlock()
+
+	s.buffer.close()
+}
+
+// Dropped - the number of events lost because the subscriber's buffer was
+// full
+func (s *Subscription) Dropped() uint64 {
+	_, dropped := s.buffer.stats()
+	return dropped
+}
+
+// Events - the events (LogMessage, KeyPress, Command, ...) received on this
+// connection. The channel is closed by Destroy. Events are buffered from
+// the first call on, a connection that never asks for the channel does
+// not buffer (or, with Block, wait) for it.
+func (c *Connection) Events() <-chan interface{} {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.stream == nil && !c.closed {
+		c.stream = c.newSubscription(nil, func(event interface{}) {
+			select {
+			case c.events <- event:
+			case <-c.done:
+			}
+		})
+		c.attach(c.stream)
+		go c.stream.run()
+	}
+	return c.events
+}
+
+// DroppedEvents - the number of events lost because the Events channel, a
+// subscriber or the backend's queue was not keeping up
+func (c *Connection) DroppedEvents() uint64 {
+	c.mu.Lock()
+	dropped := c.dropped
+	for _, s := range c.subs {
+		dropped += s.Dropped()
+	}
+	c.mu.Unlock()
+
+	if q, ok := c.backend.(EventQueue); ok {
+		dropped += q.DroppedEvents()
+	}
+	return dropped
+}
+
+// BufferedEvents - the number of events waiting to be read from Events
+func (c *Connection) BufferedEvents() int {
+	c.mu.Lock()
+	stream := c.stream
+	c.mu.Unlock()
+
+	if stream == nil {
+		return 0
+	}
+	buffered, _ := stream.buffer.stats()
+	return buffered
+}
+
+func containsOpcode(list []Opcode, opcode Opcode) bool {
+	for _, o := range list {
+		if o == opcode {
+			return true
+		}
+	}
+	return false
+}
+
+func containsAddress(list []LogicalAddress, v LogicalAddress) bool {
+	for _, i := range list {
+		if i == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,332 @@
+package cec
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Timer - the date, start time and duration of a timer recording. The
+// year is not transmitted, the recorder programs the next matching date.
+type Timer struct {
+	// Day - day of month (1-31)
+	Day   int
+	Month time.Month
+	// Hour, Minute - the start time (0-23, 0-59)
+	Hour   int
+	Minute int
+	// Duration - up to 99:59, in minutes
+	Duration time.Duration
+	// Repeat - the weekdays to record on, RecordOnce for a single
+	// recording
+	Repeat RecordingSequence
+}
+
+// NewTimer - a single recording starting at the given time (its local
+// date and time), seconds are dropped
+func NewTimer(start time.Time, duration time.Duration) Timer {
+	return Timer{Day: start.Day(), Month: start.Month(), Hour: start.Hour(),
+		Minute: start.Minute(), Duration: duration.Truncate(time.Minute)}
+}
+
+func (t Timer) String() string {
+	s := fmt.Sprintf("%02d-%02d %02d:%02d +%dh%02dm", int(t.Month), t.Day, t.Hour, t.Minute,
+		int(t.Duration.Hours()), int(t.Duration.Minutes())%60)
+	if t.Repeat != RecordOnce {
+		s += " " + t.Repeat.String()
+	}
+	return s
+}
+
+// RecordingSequence - the weekdays a timer repeats on, one bit per
+// time.Weekday
+type RecordingSequence byte
+
+// RecordOnce - a timer that does not repeat
+const RecordOnce RecordingSequence = 0
+
+// Repeat - a timer repeating on the given weekdays
+func Repeat(days ...time.Weekday) RecordingSequence {
+	var s RecordingSequence
+	for _, day := range days {
+		s |= 1 << uint(day)
+	}
+	return s
+}
+
+// Has - whether the timer repeats on the weekday
+func (s RecordingSequence) Has(day time.Weekday) bool {
+	return s&(1<<uint(day)) != 0
+}
+
+func (s RecordingSequence) String() string {
+	if s == RecordOnce {
+		return "once"
+	}
+	var days []string
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		if s.Has(day) {
+			days = append(days, day.String()[:3])
+		}
+	}
+	return strings.Join(days, ",")
+}
+
+// MediaInfo - the media state in Timer Status
+type MediaInfo byte
+
+// media info as used on the bus
+const (
+	MediaPresent    MediaInfo = 0x00
+	MediaProtected  MediaInfo = 0x01
+	MediaNotPresent MediaInfo = 0x02
+)
+
+var mediaInfoNames = []string{"present", "protected", "not present"}
+
+// IsValid - whether the info is defined by the standard
+func (i MediaInfo) IsValid() bool {
+	return int(i) < len(mediaInfoNames)
+}
+
+func (i MediaInfo) String() string {
+	if !i.IsValid() {
+		return "Unknown"
+	}
+	return mediaInfoNames[i]
+}
+
+// ProgrammedInfo - the space available for a programmed timer
+type ProgrammedInfo byte
+
+// programmed info as used on the bus
+const (
+	ProgrammedEnoughSpace      ProgrammedInfo = 0x08
+	ProgrammedNotEnoughSpace   ProgrammedInfo = 0x09
+	ProgrammedNoMediaInfo      ProgrammedInfo = 0x0A
+	ProgrammedMaybeEnoughSpace ProgrammedInfo = 0x0B
+)
+
+var programmedInfoNames = map[ProgrammedInfo]string{
+	ProgrammedEnoughSpace: "enough space", ProgrammedNotEnoughSpace: "not enough space",
+	ProgrammedNoMediaInfo: "no media info", ProgrammedMaybeEnoughSpace: "may not be enough space"}
+
+// IsValid - whether the info is defined by the standard
+func (i ProgrammedInfo) IsValid() bool {
+	_, ok := programmedInfoNames[i]
+	return ok
+}
+
+func (i ProgrammedInfo) String() string {
+	if name, ok := programmedInfoNames[i]; ok {
+		return name
+	}
+	return "Unknown"
+}
+
+// NotProgrammedReason - why a timer was not programmed
+type NotProgrammedReason byte
+
+// not programmed error info as used on the bus
+const (
+	NotProgrammedNoFreeTimer      NotProgrammedReason = 0x01
+	NotProgrammedDateOutOfRange   NotProgrammedReason = 0x02
+	NotProgrammedSequenceError    NotProgrammedReason = 0x03
+	NotProgrammedInvalidPlug      NotProgrammedReason = 0x04
+	NotProgrammedInvalidAddress   NotProgrammedReason = 0x05
+	NotProgrammedCANotSupported   NotProgrammedReason = 0x06
+	NotProgrammedNoCAEntitlements NotProgrammedReason = 0x07
+	NotProgrammedResolution       NotProgrammedReason = 0x08
+	NotProgrammedParentalLock     NotProgrammedReason = 0x09
+	NotProgrammedClockFailure     NotProgrammedReason = 0x0A
+	NotProgrammedDuplicate        NotProgrammedReason = 0x0E
+)
+
+var notProgrammedReasonNames = map[NotProgrammedReason]string{
+	NotProgrammedNoFreeTimer:      "no free timer",
+	NotProgrammedDateOutOfRange:   "date out of range",
+	NotProgrammedSequenceError:    "recording sequence error",
+	NotProgrammedInvalidPlug:      "invalid external plug",
+	NotProgrammedInvalidAddress:   "invalid external physical address",
+	NotProgrammedCANotSupported:   "CA system not supported",
+	NotProgrammedNoCAEntitlements: "no CA entitlements",
+	NotProgrammedResolution:       "resolution not supported",
+	NotProgrammedParentalLock:     "parental lock on",
+	NotProgrammedClockFailure:     "clock failure",
+	NotProgrammedDuplicate:        "already programmed"}
+
+// IsValid - whether the reason is defined by the standard
+func (r NotProgrammedReason) IsValid() bool {
+	_, ok := notProgrammedReasonNames[r]
+	return ok
+}
+
+func (r NotProgrammedReason) String() string {
+	if name, ok := notProgrammedReasonNames[r]; ok {
+		return name
+	}
+	return "Unknown"
+}
+
+// TimerClearedInfo - the operand of Timer Cleared Status
+type TimerClearedInfo byte
+
+// timer cleared status data as used on the bus
+const (
+	TimerNotClearedRecording TimerClearedInfo = 0x00
+	TimerNotClearedNoMatch   TimerClearedInfo = 0x01
+	TimerNotClearedNoInfo    TimerClearedInfo = 0x02
+	TimerCleared             TimerClearedInfo = 0x80
+)
+
+var timerClearedInfoNames = map[TimerClearedInfo]string{
+	TimerNotClearedRecording: "not cleared, recording",
+	TimerNotClearedNoMatch:   "not cleared, no matching timer",
+	TimerNotClearedNoInfo:    "not cleared, no info available",
+	TimerCleared:             "cleared"}
+
+// IsValid - whether the info is defined by the standard
+func (i TimerClearedInfo) IsValid() bool {
+	_, ok := timerClearedInfoNames[i]
+	return ok
+}
+
+func (i TimerClearedInfo) String() string {
+	if name, ok := timerClearedInfoNames[i]; ok {
+		return name
+	}
+	return "Unknown"
+}
+
+// SetAnalogueTimer - program a timer recording an analogue service
+func (c *Connection) SetAnalogueTimer(ctx context.Context, address LogicalAddress, timer Timer, service AnalogueService) (TimerStatus, error) {
+	return c.setTimer(ctx, address, SetAnalogueTimer{Timer: timer, Service: service})
+}
+
+// SetDigitalTimer - program a timer recording a digital service
+func (c *Connection) SetDigitalTimer(ctx context.Context, address LogicalAddress, timer Timer, service DigitalService) (TimerStatus, error) {
+	return c.setTimer(ctx, address, SetDigitalTimer{Timer: timer, Service: service})
+}
+
+// SetExternalTimer - program a timer recording an external plug or
+// physical address (a source of type RecordExternalPlug or
+// RecordExternalPhysicalAddress)
+func (c *Connection) SetExternalTimer(ctx context.Context, address LogicalAddress, timer Timer, source RecordSource) (TimerStatus, error) {
+	return c.setTimer(ctx, address, SetExternalTimer{Timer: timer, Source: source})
+}
+
+// ClearAnalogueTimer - delete a timer set with SetAnalogueTimer
+func (c *Connection) ClearAnalogueTimer(ctx context.Context, address LogicalAddress, timer Timer, service AnalogueService) (TimerClearedInfo, error) {
+	return c.clearTimer(ctx, address, ClearAnalogueTimer{Timer: timer, Service: service})
+}
+
+// ClearDigitalTimer - delete a timer set with SetDigitalTimer
+func (c *Connection) ClearDigitalTimer(ctx context.Context, address LogicalAddress, timer Timer, service DigitalService) (TimerClearedInfo, error) {
+	return c.clearTimer(ctx, address, ClearDigitalTimer{Timer: timer, Service: service})
+}
+
+// ClearExternalTimer - delete a timer set with SetExternalTimer
+func (c *Connection) ClearExternalTimer(ctx context.Context, address LogicalAddress, timer Timer, source RecordSource) (TimerClearedInfo, error) {
+	return c.clearTimer(ctx, address, ClearExternalTimer{Timer: timer, Source: source})
+}
+
+// SetTimerProgramTitle - name the timer programmed last (1-14 characters)
+func (c *Connection) SetTimerProgramTitle(address LogicalAddress, title string) error {
+	return c.Send(address, SetTimerProgramTitle{Title: title})
+}
+
+func (c *Connection) setTimer(ctx context.Context, address LogicalAddress, msg Message) (TimerStatus, error) {
+	reply, err := c.Request(ctx, address, msg)
+	if err != nil {
+		return TimerStatus{}, err
+	}
+	status, ok := reply.(TimerStatus)
+	if !ok {
+		return TimerStatus{}, unexpectedReply(msg.Opcode(), reply)
+	}
+	return status, nil
+}
+
+func (c *Connection) clearTimer(ctx context.Context, address LogicalAddress, msg Message) (TimerClearedInfo, error) {
+	reply, err := c.Request(ctx, address, msg)
+	if err != nil {
+		return 0, err
+	}
+	status, ok := reply.(TimerClearedStatus)
+	if !ok {
+		return 0, unexpectedReply(msg.Opcode(), reply)
+	}
+	return status.Status, nil
+}
+
+func bcd(n int) byte {
+	return byte(n/10<<4 | n%10)
+}
+
+func bcdOperand(b byte) int {
+	return int(b>>4)*10 + int(b&0xF)
+}
+
+// timerOperand - decode the 7 byte day, month, start time, duration and
+// recording sequence
+func timerOperand(b []byte) Timer {
+	return Timer{
+		Day:      int(b[0]),
+		Month:    time.Month(b[1]),
+		Hour:     bcdOperand(b[2]),
+		Minute:   bcdOperand(b[3]),
+		Duration: durationOperand(b[4:]),
+		Repeat:   RecordingSequence(b[6]),
+	}
+}
+
+func appendTimer(b []byte, t Timer) ([]byte, error) {
+	switch {
+	case t.Day < 1 || t.Day > 31:
+		return nil, fmt.Errorf("%w: invalid day of month %d", ErrInvalidFrame, t.Day)
+	case t.Month < time.January || t.Month > time.December:
+		return nil, fmt.Errorf("%w: invalid month %d", ErrInvalidFrame, t.Month)
+	case t.Hour < 0 || t.Hour > 23 || t.Minute < 0 || t.Minute > 59:
+		return nil, fmt.Errorf("%w: invalid start time %02d:%02d", ErrInvalidFrame, t.Hour, t.Minute)
+	case t.Repeat&0x80 != 0:
+		return nil, fmt.Errorf("%w: invalid recording sequence 0x%02x", ErrInvalidFrame, byte(t.Repeat))
+	}
+
+	b = append(b, byte(t.Day), byte(t.Month), bcd(t.Hour), bcd(t.Minute))
+	b, err := appendDuration(b, t.Duration)
+	if err != nil {
+		return nil, err
+	}
+	return append(b, byte(t.Repeat)), nil
+}
+
+// durationOperand - decode the BCD hours and minutes of a duration
+func durationOperand(b []byte) time.Duration {
+	return time.Duration(bcdOperand(b[0]))*time.Hour + time.Duration(bcdOperand(b[1]))*time.Minute
+}
+
+func appendDuration(b []byte, d time.Duration) ([]byte, error) {
+	if d < 0 || d >= 100*time.Hour {
+		return nil, fmt.Errorf("%w: invalid duration %s", ErrInvalidFrame, d)
+	}
+	minutes := int(d / time.Minute)
+	return append(b, bcd(minutes/60), bcd(minutes%60)), nil
+}
+
+// externalSourceOperand - decode the external source specifier and plug or
+// physical address of the external timers
+func externalSourceOperand(opcode Opcode, b []byte) (RecordSource, error) {
+	s, err := recordSourceOperand(opcode, b)
+	if err == nil && s.Type != RecordExternalPlug && s.Type != RecordExternalPhysicalAddress {
+		err = fmt.Errorf("%w: %s: invalid external source specifier %d", ErrInvalidFrame, opcode, s.Type)
+	}
+	return s, err
+}
+
+func appendExternalSource(b []byte, s RecordSource) ([]byte, error) {
+	if s.Type != RecordExternalPlug && s.Type != RecordExternalPhysicalAddress {
+		return nil, fmt.Errorf("%w: invalid external source %s", ErrInvalidFrame, s.Type)
+	}
+	return appendRecordSource(b, s)
+}
@@ -0,0 +1,67 @@
+package cec_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/chbmuc/cec"
+	"github.com/chbmuc/cec/cectest"
+)
+
+func TestDigitalTimer(t *testing.T) {
+	c, _ := openBus(t, cectest.NewTV(), cectest.NewRecorder(1, 0x2000, "Recorder"))
+	ctx := context.Background()
+	timer := cec.NewTimer(time.Date(2024, time.May, 4, 20, 15, 0, 0, time.Local), 90*time.Minute)
+	service := cec.DigitalService{System: cec.DVB, TransportStreamID: 1, ServiceID: 1, OriginalNetworkID: 1}
+
+	status, err := c.SetDigitalTimer(ctx, cec.Recorder1, timer, service)
+	if err != nil || !status.Programmed || status.Info != cec.ProgrammedEnoughSpace {
+		t.Errorf("SetDigitalTimer = %+v, %v, want programmed", status, err)
+	}
+	status, err = c.SetDigitalTimer(ctx, cec.Recorder1, timer, service)
+	if err != nil || status.Programmed || status.Reason != cec.NotProgrammedDuplicate {
+		t.Errorf("SetDigitalTimer again = %+v, %v, want a duplicate", status, err)
+	}
+
+	cleared, err := c.ClearDigitalTimer(ctx, cec.Recorder1, timer, service)
+	if err != nil || cleared != cec.TimerCleared {
+		t.Errorf("ClearDigitalTimer = %v, %v, want cleared", cleared, err)
+	}
+	cleared, err = c.ClearDigitalTimer(ctx, cec.Recorder1, timer, service)
+	if err != nil || cleared != cec.TimerNotClearedNoMatch {
+		t.Errorf("ClearDigitalTimer again = %v, %v, want no matching timer", cleared, err)
+	}
+}
+
+func TestDigitalTimerMalformedReply(t *testing.T) {
+	recorder := cectest.NewRecorder(1, 0x2000, "Recorder")
+	// Timer Status without its operand
+	recorder.Handler = replying(0x97, []byte{0x14, 0x35})
+	c, _ := openBus(t, cectest.NewTV(), recorder)
+
+	timer := cec.NewTimer(time.Date(2024, time.May, 4, 20, 15, 0, 0, time.Local), time.Hour)
+	service := cec.DigitalService{System: cec.DVB, TransportStreamID: 1, ServiceID: 1, OriginalNetworkID: 1}
+	if _, err := c.SetDigitalTimer(context.Background(), cec.Recorder1, timer, service); !errors.Is(err, cec.ErrInvalidFrame) {
+		t.Errorf("SetDigitalTimer = %v, want ErrInvalidFrame", err)
+	}
+}
+
+func TestNotProgrammedReason(t *testing.T) {
+	tests := []struct {
+		reason cec.NotProgrammedReason
+		want   string
+	}{
+		{cec.NotProgrammedNoFreeTimer, "no free timer"},
+		{cec.NotProgrammedSequenceError, "recording sequence error"},
+		{cec.NotProgrammedDuplicate, "already programmed"},
+		{0x0B, "Unknown"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.reason.String(); got != tt.want {
+			t.Errorf("NotProgrammedReason(0x%02x) = %q, want %q", byte(tt.reason), got, tt.want)
+		}
+	}
+}
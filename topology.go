@@ -0,0 +1,125 @@
+package cec
+
+import (
+	"context"
+	"sort"
+)
+
+// TopologyNode - a device in the HDMI tree
+type TopologyNode struct {
+	Device
+	// Port - the port of the parent the device is connected to
+	Port     int
+	Children []*TopologyNode
+}
+
+// Topology - the HDMI network as seen from the bus
+type Topology struct {
+	// Root - the TV at 0.0.0.0. If the TV did not answer it is a node
+	// without OSD name and with unknown power status.
+	Root *TopologyNode
+	// Duplicates - physical addresses reported by more than one logical
+	// address. Devices with several logical addresses (an AVR with a
+	// tuner) do this legitimately, the extra devices are siblings in the
+	// tree.
+	Duplicates map[PhysicalAddress][]LogicalAddress
+	// Invalid - devices reporting an invalid physical address (f.f.f.f or
+	// a port after a zero), they are not in the tree
+	Invalid []Device
+}
+
+// Topology - the HDMI tree of the active devices. Devices behind a switch
+// without CEC are connected to the closest device above them.
+func (c *Connection) Topology() *Topology {
+	return newTopology(c.List())
+}
+
+// TopologyContext - Topology with a context
+func (c *Connection) TopologyContext(ctx context.Context) (*Topology, error) {
+	devices, err := c.ListContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return newTopology(devices), nil
+}
+
+// Find - the (first) node with the given physical address, nil if there
+// is none
+func (t *Topology) Find(address PhysicalAddress) *TopologyNode {
+	var find func(n *TopologyNode) *TopologyNode
+	find = func(n *TopologyNode) *TopologyNode {
+		if n.PhysicalAddress == address {
+			return n
+		}
+		for _, child := range n.Children {
+			if found := find(child); found != nil {
+				return found
+			}
+		}
+		return nil
+	}
+	return find(t.Root)
+}
+
+func newTopology(devices map[string]Device) *Topology {
+	t := &Topology{Duplicates: make(map[PhysicalAddress][]LogicalAddress)}
+
+	// by physical address, parents before their children
+	var list []Device
+	byAddress := make(map[PhysicalAddress][]LogicalAddress)
+	for _, dev := range devices {
+		if !dev.PhysicalAddress.IsValid() {
+			t.Invalid = append(t.Invalid, dev)
+			continue
+		}
+		list = append(list, dev)
+		byAddress[dev.PhysicalAddress] = append(byAddress[dev.PhysicalAddress], dev.LogicalAddress)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].PhysicalAddress != list[j].PhysicalAddress {
+			return list[i].PhysicalAddress < list[j].PhysicalAddress
+		}
+		return list[i].LogicalAddress < list[j].LogicalAddress
+	})
+	sort.Slice(t.Invalid, func(i, j int) bool {
+		return t.Invalid[i].LogicalAddress < t.Invalid[j].LogicalAddress
+	})
+	for address, logical := range byAddress {
+		if len(logical) > 1 {
+			sort.Slice(logical, func(i, j int) bool { return logical[i] < logical[j] })
+			t.Duplicates[address] = logical
+		}
+	}
+
+	if len(byAddress[RootPhysicalAddress]) == 0 {
+		t.Root = &TopologyNode{Device: Device{LogicalAddress: TV,
+			LogicalAddressName: TV.String(), PowerStatus: PowerStatusUnknown}}
+	}
+
+	nodes := make(map[PhysicalAddress]*TopologyNode)
+	nodes[RootPhysicalAddress] = t.Root
+	for _, dev := range list {
+		node := &TopologyNode{Device: dev, Port: dev.PhysicalAddress.Port()}
+
+		if t.Root == nil {
+			// the first device at 0.0.0.0
+			t.Root = node
+			nodes[RootPhysicalAddress] = node
+			continue
+		}
+		if dev.PhysicalAddress == RootPhysicalAddress {
+			t.Root.Children = append(t.Root.Children, node)
+			continue
+		}
+
+		parent := dev.PhysicalAddress.Parent()
+		for nodes[parent] == nil {
+			parent = parent.Parent()
+		}
+		nodes[parent].Children = append(nodes[parent].Children, node)
+		if nodes[dev.PhysicalAddress] == nil {
+			nodes[dev.PhysicalAddress] = node
+		}
+	}
+	return t
+}
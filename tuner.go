@@ -0,0 +1,310 @@
+package cec
+
+import (
+	"context"
+	"fmt"
+)
+
+// DigitalBroadcastSystem - the broadcast system of a digital service
+type DigitalBroadcastSystem byte
+
+// digital broadcast systems as used on the bus
+const (
+	ARIB            DigitalBroadcastSystem = 0x00
+	ATSC            DigitalBroadcastSystem = 0x01
+	DVB             DigitalBroadcastSystem = 0x02
+	ARIBBS          DigitalBroadcastSystem = 0x08
+	ARIBCS          DigitalBroadcastSystem = 0x09
+	ARIBT           DigitalBroadcastSystem = 0x0A
+	ATSCCable       DigitalBroadcastSystem = 0x10
+	ATSCSatellite   DigitalBroadcastSystem = 0x11
+	ATSCTerrestrial DigitalBroadcastSystem = 0x12
+	DVBC            DigitalBroadcastSystem = 0x18
+	DVBS            DigitalBroadcastSystem = 0x19
+	DVBS2           DigitalBroadcastSystem = 0x1A
+	DVBT            DigitalBroadcastSystem = 0x1B
+)
+
+var digitalBroadcastSystemNames = map[DigitalBroadcastSystem]string{
+	ARIB: "ARIB", ATSC: "ATSC", DVB: "DVB", ARIBBS: "ARIB-BS", ARIBCS: "ARIB-CS",
+	ARIBT: "ARIB-T", ATSCCable: "ATSC cable", ATSCSatellite: "ATSC satellite",
+	ATSCTerrestrial: "ATSC terrestrial", DVBC: "DVB-C", DVBS: "DVB-S",
+	DVBS2: "DVB-S2", DVBT: "DVB-T"}
+
+// IsValid - whether the system is defined by the standard
+func (s DigitalBroadcastSystem) IsValid() bool {
+	_, ok := digitalBroadcastSystemNames[s]
+	return ok
+}
+
+func (s DigitalBroadcastSystem) String() string {
+	if name, ok := digitalBroadcastSystemNames[s]; ok {
+		return name
+	}
+	return "Unknown"
+}
+
+// IsATSC - whether the system is one of the ATSC systems, they identify a
+// service by transport stream ID and program number only
+func (s DigitalBroadcastSystem) IsATSC() bool {
+	return s == ATSC || s >= ATSCCable && s <= ATSCTerrestrial
+}
+
+// ChannelNumber - a 1-part (Minor only) or 2-part (Major.Minor) channel
+// number
+type ChannelNumber struct {
+	TwoPart bool
+	// Major - 0 to 1023, only for 2-part numbers
+	Major uint16
+	Minor uint16
+}
+
+func (n ChannelNumber) String() string {
+	if n.TwoPart {
+		return fmt.Sprintf("%d.%d", n.Major, n.Minor)
+	}
+	return fmt.Sprintf("%d", n.Minor)
+}
+
+// DigitalService - a digital service identified by its IDs or by its
+// channel number (ByChannel)
+type DigitalService struct {
+	System            DigitalBroadcastSystem
+	TransportStreamID uint16
+	// ServiceID - the service_id of ARIB and DVB, the program_number of
+	// ATSC
+	ServiceID uint16
+	// OriginalNetworkID - ARIB and DVB only
+	OriginalNetworkID uint16
+
+	ByChannel bool
+	Channel   ChannelNumber
+}
+
+func (s DigitalService) String() string {
+	if s.ByChannel {
+		return fmt.Sprintf("%s channel %s", s.System, s.Channel)
+	}
+	if s.System.IsATSC() {
+		return fmt.Sprintf("%s %d/%d", s.System, s.TransportStreamID, s.ServiceID)
+	}
+	return fmt.Sprintf("%s %d/%d/%d", s.System, s.OriginalNetworkID, s.TransportStreamID, s.ServiceID)
+}
+
+// AnalogueBroadcastType - cable, satellite or terrestrial
+type AnalogueBroadcastType byte
+
+// analogue broadcast types as used on the bus
+const (
+	AnalogueCable       AnalogueBroadcastType = 0x00
+	AnalogueSatellite   AnalogueBroadcastType = 0x01
+	AnalogueTerrestrial AnalogueBroadcastType = 0x02
+)
+
+var analogueBroadcastTypeNames = []string{"cable", "satellite", "terrestrial"}
+
+// IsValid - whether the type is defined by the standard
+func (t AnalogueBroadcastType) IsValid() bool {
+	return int(t) < len(analogueBroadcastTypeNames)
+}
+
+func (t AnalogueBroadcastType) String() string {
+	if !t.IsValid() {
+		return "Unknown"
+	}
+	return analogueBroadcastTypeNames[t]
+}
+
+// BroadcastSystem - the analogue TV system
+type BroadcastSystem byte
+
+// broadcast systems as used on the bus
+const (
+	PALBG                BroadcastSystem = 0x00
+	SECAMLPrime          BroadcastSystem = 0x01
+	PALM                 BroadcastSystem = 0x02
+	NTSCM                BroadcastSystem = 0x03
+	PALI                 BroadcastSystem = 0x04
+	SECAMDK              BroadcastSystem = 0x05
+	SECAMBG              BroadcastSystem = 0x06
+	SECAML               BroadcastSystem = 0x07
+	PALDK                BroadcastSystem = 0x08
+	OtherBroadcastSystem BroadcastSystem = 0x1F
+)
+
+var broadcastSystemNames = map[BroadcastSystem]string{PALBG: "PAL B/G",
+	SECAMLPrime: "SECAM L'", PALM: "PAL M", NTSCM: "NTSC M", PALI: "PAL I",
+	SECAMDK: "SECAM DK", SECAMBG: "SECAM B/G", SECAML: "SECAM L",
+	PALDK: "PAL DK", OtherBroadcastSystem: "other"}
+
+// IsValid - whether the system is defined by the standard
+func (s BroadcastSystem) IsValid() bool {
+	_, ok := broadcastSystemNames[s]
+	return ok
+}
+
+func (s BroadcastSystem) String() string {
+	if name, ok := broadcastSystemNames[s]; ok {
+		return name
+	}
+	return "Unknown"
+}
+
+// AnalogueService - an analogue channel
+type AnalogueService struct {
+	Type AnalogueBroadcastType
+	// Frequency - in steps of 62.5 kHz (0x0001 to 0xFFFE)
+	Frequency uint16
+	System    BroadcastSystem
+}
+
+// FrequencyMHz - the frequency in MHz
+func (s AnalogueService) FrequencyMHz() float64 {
+	return float64(s.Frequency) * 0.0625
+}
+
+func (s AnalogueService) String() string {
+	return fmt.Sprintf("%s %.2f MHz %s", s.Type, s.FrequencyMHz(), s.System)
+}
+
+// TunerDisplayInfo - what the tuner device shows
+type TunerDisplayInfo byte
+
+// tuner display info as used on the bus
+const (
+	TunerDisplayDigital  TunerDisplayInfo = 0x00
+	TunerDisplayNone     TunerDisplayInfo = 0x01
+	TunerDisplayAnalogue TunerDisplayInfo = 0x02
+)
+
+var tunerDisplayInfoNames = []string{"digital", "none", "analogue"}
+
+// IsValid - whether the info is defined by the standard
+func (i TunerDisplayInfo) IsValid() bool {
+	return int(i) < len(tunerDisplayInfoNames)
+}
+
+func (i TunerDisplayInfo) String() string {
+	if !i.IsValid() {
+		return "Unknown"
+	}
+	return tunerDisplayInfoNames[i]
+}
+
+// SelectDigitalService - tune the device to a digital service
+func (c *Connection) SelectDigitalService(address LogicalAddress, service DigitalService) error {
+	return c.Send(address, SelectDigitalService{Service: service})
+}
+
+// SelectAnalogueService - tune the device to an analogue channel
+func (c *Connection) SelectAnalogueService(address LogicalAddress, service AnalogueService) error {
+	return c.Send(address, SelectAnalogueService{Service: service})
+}
+
+// TunerStepIncrement - tune the device to the next service
+func (c *Connection) TunerStepIncrement(address LogicalAddress) error {
+	return c.Send(address, TunerStepIncrement{})
+}
+
+// TunerStepDecrement - tune the device to the previous service
+func (c *Connection) TunerStepDecrement(address LogicalAddress) error {
+	return c.Send(address, TunerStepDecrement{})
+}
+
+// GiveTunerDeviceStatus - ask a device for its tuner status once or on
+// every change (StatusRequestOn, the later reports are published as
+// commands, see OnTunerDeviceStatus) and returns the first report.
+// StatusRequestOff stops the reports and returns no status.
+func (c *Connection) GiveTunerDeviceStatus(ctx context.Context, address LogicalAddress, request StatusRequest) (TunerDeviceStatus, error) {
+	if request == StatusRequestOff {
+		return TunerDeviceStatus{}, c.SendContext(ctx, address, GiveTunerDeviceStatus{Request: request})
+	}
+
+	reply, err := c.Request(ctx, address, GiveTunerDeviceStatus{Request: request})
+	if err != nil {
+		return TunerDeviceStatus{}, err
+	}
+	status, ok := reply.(TunerDeviceStatus)
+	if !ok {
+		return TunerDeviceStatus{}, unexpectedReply(OpGiveTunerDeviceStatus, reply)
+	}
+	return status, nil
+}
+
+// OnTunerDeviceStatus - call fn for every Tuner Device Status received
+func (c *Connection) OnTunerDeviceStatus(fn func(source LogicalAddress, status TunerDeviceStatus)) *Subscription {
+	return c.OnCommand(func(cmd Command) {
+		if m, ok := cmd.Message.(TunerDeviceStatus); ok {
+			fn(cmd.Initiator, m)
+		}
+	}, OpTunerDeviceStatus)
+}
+
+// digitalServiceOperand - decode the 7 byte Digital Service Identification
+func digitalServiceOperand(b []byte) DigitalService {
+	s := DigitalService{System: DigitalBroadcastSystem(b[0] & 0x7F)}
+	if b[0]&0x80 != 0 {
+		s.ByChannel = true
+		s.Channel = ChannelNumber{
+			TwoPart: b[1]>>2 == 0x02,
+			Major:   uint16(b[1]&0x03)<<8 | uint16(b[2]),
+			Minor:   uint16(b[3])<<8 | uint16(b[4]),
+		}
+		return s
+	}
+
+	s.TransportStreamID = uint16(b[1])<<8 | uint16(b[2])
+	s.ServiceID = uint16(b[3])<<8 | uint16(b[4])
+	if !s.System.IsATSC() {
+		s.OriginalNetworkID = uint16(b[5])<<8 | uint16(b[6])
+	}
+	return s
+}
+
+func appendDigitalService(b []byte, s DigitalService) ([]byte, error) {
+	if !s.System.IsValid() {
+		return nil, fmt.Errorf("%w: invalid digital broadcast system %d", ErrInvalidFrame, s.System)
+	}
+
+	if s.ByChannel {
+		format := byte(0x01)
+		if s.Channel.TwoPart {
+			format = 0x02
+		}
+		if s.Channel.Major > 0x3FF {
+			return nil, fmt.Errorf("%w: major channel number out of range", ErrInvalidFrame)
+		}
+		return append(b, 0x80|byte(s.System), format<<2|byte(s.Channel.Major>>8), byte(s.Channel.Major),
+			byte(s.Channel.Minor>>8), byte(s.Channel.Minor), 0, 0), nil
+	}
+
+	network := s.OriginalNetworkID
+	if s.System.IsATSC() {
+		network = 0 // reserved
+	}
+	return append(b, byte(s.System), byte(s.TransportStreamID>>8), byte(s.TransportStreamID),
+		byte(s.ServiceID>>8), byte(s.ServiceID), byte(network>>8), byte(network)), nil
+}
+
+// analogueServiceOperand - decode the 4 byte analogue broadcast type,
+// frequency and broadcast system
+func analogueServiceOperand(b []byte) AnalogueService {
+	return AnalogueService{
+		Type:      AnalogueBroadcastType(b[0]),
+		Frequency: uint16(b[1])<<8 | uint16(b[2]),
+		System:    BroadcastSystem(b[3]),
+	}
+}
+
+func appendAnalogueService(b []byte, s AnalogueService) ([]byte, error) {
+	if !s.Type.IsValid() {
+		return nil, fmt.Errorf("%w: invalid analogue broadcast type %d", ErrInvalidFrame, s.Type)
+	}
+	if s.Frequency == 0x0000 || s.Frequency == 0xFFFF {
+		return nil, fmt.Errorf("%w: invalid analogue frequency 0x%04x", ErrInvalidFrame, s.Frequency)
+	}
+	if !s.System.IsValid() {
+		return nil, fmt.Errorf("%w: invalid broadcast system %d", ErrInvalidFrame, s.System)
+	}
+	return append(b, byte(s.Type), byte(s.Frequency>>8), byte(s.Frequency), byte(s.System)), nil
+}
@@ -0,0 +1,46 @@
+package cec_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/chbmuc/cec"
+	"github.com/chbmuc/cec/cectest"
+)
+
+func TestTuner(t *testing.T) {
+	c, _ := openBus(t, cectest.NewTV(), cectest.NewTuner(3, 0x2000, "STB"))
+	ctx := context.Background()
+
+	services := []struct {
+		service cec.DigitalService
+		want    string
+	}{
+		{service: cec.DigitalService{System: cec.DVBT, OriginalNetworkID: 8468, TransportStreamID: 769, ServiceID: 28106},
+			want: "DVB-T 8468/769/28106"},
+		{service: cec.DigitalService{System: cec.ATSCTerrestrial, TransportStreamID: 42, ServiceID: 3},
+			want: "ATSC terrestrial 42/3"},
+		{service: cec.DigitalService{System: cec.ATSCTerrestrial, ByChannel: true,
+			Channel: cec.ChannelNumber{TwoPart: true, Major: 7, Minor: 1}},
+			want: "ATSC terrestrial channel 7.1"},
+	}
+
+	status, err := c.GiveTunerDeviceStatus(ctx, cec.Tuner1, cec.StatusRequestOnce)
+	if err != nil || status.Digital == nil || status.Digital.String() != "DVB-T 1/1/1" {
+		t.Fatalf("GiveTunerDeviceStatus = %+v, %v, want DVB-T 1/1/1", status, err)
+	}
+
+	for _, s := range services {
+		if err := c.SelectDigitalService(cec.Tuner1, s.service); err != nil {
+			t.Fatalf("SelectDigitalService(%s): %v", s.want, err)
+		}
+		status, err := c.GiveTunerDeviceStatus(ctx, cec.Tuner1, cec.StatusRequestOnce)
+		if err != nil || status.Display != cec.TunerDisplayDigital || status.Digital == nil || *status.Digital != s.service {
+			t.Errorf("GiveTunerDeviceStatus = %+v, %v, want %s", status, err, s.want)
+			continue
+		}
+		if got := status.Digital.String(); got != s.want {
+			t.Errorf("service = %q, want %q", got, s.want)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package cec
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// LogicalAddress - the logical address of a device on the bus (0-15)
+type LogicalAddress int
+
+// logical addresses
+const (
+	TV           LogicalAddress = 0x0
+	Recorder1    LogicalAddress = 0x1
+	Recorder2    LogicalAddress = 0x2
+	Tuner1       LogicalAddress = 0x3
+	Playback1    LogicalAddress = 0x4
+	AudioSystem  LogicalAddress = 0x5
+	Tuner2       LogicalAddress = 0x6
+	Tuner3       LogicalAddress = 0x7
+	Playback2    LogicalAddress = 0x8
+	Recorder3    LogicalAddress = 0x9
+	Tuner4       LogicalAddress = 0xA
+	Playback3    LogicalAddress = 0xB
+	Backup1      LogicalAddress = 0xC
+	Backup2      LogicalAddress = 0xD
+	SpecificUse  LogicalAddress = 0xE
+	Broadcast    LogicalAddress = 0xF
+	Unregistered LogicalAddress = 0xF // as initiator
+)
+
+// IsValid - whether the address is in range
+func (a LogicalAddress) IsValid() bool {
+	return a >= 0 && a <= 0xF
+}
+
+func (a LogicalAddress) String() string {
+	return GetLogicalNameByAddress(int(a))
+}
+
+// MarshalText - the name of the address (as String)
+func (a LogicalAddress) MarshalText() ([]byte, error) {
+	if !a.IsValid() {
+		return nil, fmt.Errorf("cec: invalid logical address %d", int(a))
+	}
+	return []byte(a.String()), nil
+}
+
+// UnmarshalText - accepts the names of String and GetLogicalAddressByName
+// ("Playback 2", "playback2") or the number ("8", "0x8")
+func (a *LogicalAddress) UnmarshalText(text []byte) error {
+	address, err := ParseLogicalAddress(string(text))
+	if err != nil {
+		return err
+	}
+	*a = address
+	return nil
+}
+
+// ParseLogicalAddress - parse a logical address by its name or number
+func ParseLogicalAddress(s string) (LogicalAddress, error) {
+	name := normalizeName(s)
+	for i, n := range logicalAddressNames {
+		if normalizeName(n) == name {
+			return LogicalAddress(i), nil
+		}
+	}
+	if address := GetLogicalAddressByName(s); address >= 0 {
+		return LogicalAddress(address), nil
+	}
+	if n, ok := parseNumber(s, 0xF); ok {
+		return LogicalAddress(n), nil
+	}
+	return 0, fmt.Errorf("cec: unknown logical address %q", s)
+}
+
+// DeviceType - the primary device type a device reports
+type DeviceType byte
+
+// device types
+const (
+	DeviceTypeTV          DeviceType = 0x00
+	DeviceTypeRecording   DeviceType = 0x01
+	DeviceTypeReserved    DeviceType = 0x02
+	DeviceTypeTuner       DeviceType = 0x03
+	DeviceTypePlayback    DeviceType = 0x04
+	DeviceTypeAudioSystem DeviceType = 0x05
+	DeviceTypeSwitch      DeviceType = 0x06 // pure CEC switch
+	DeviceTypeProcessor   DeviceType = 0x07 // video processor
+)
+
+var deviceTypeNames = []string{"tv", "recording", "reserved", "tuner",
+	"playback", "audio", "switch", "processor"}
+
+// IsValid - whether the device type is defined by CEC
+func (t DeviceType) IsValid() bool {
+	return int(t) < len(deviceTypeNames)
+}
+
+func (t DeviceType) String() string {
+	if !t.IsValid() {
+		return "Unknown"
+	}
+	return deviceTypeNames[t]
+}
+
+// MarshalText - the name of the device type ("playback")
+func (t DeviceType) MarshalText() ([]byte, error) {
+	if !t.IsValid() {
+		return nil, fmt.Errorf("cec: invalid device type %d", t)
+	}
+	return []byte(t.String()), nil
+}
+
+// UnmarshalText - accepts the names of String or the number
+func (t *DeviceType) UnmarshalText(text []byte) error {
+	name := normalizeName(string(text))
+	for i, n := range deviceTypeNames {
+		if n == name {
+			*t = DeviceType(i)
+			return nil
+		}
+	}
+	if n, ok := parseNumber(string(text), len(deviceTypeNames)-1); ok {
+		*t = DeviceType(n)
+		return nil
+	}
+	return fmt.Errorf("cec: unknown device type %q", text)
+}
+
+// PowerStatus - the power status a device reports
+type PowerStatus byte
+
+// power status codes as used on the bus
+const (
+	PowerStatusOn          PowerStatus = 0x00
+	PowerStatusStandby     PowerStatus = 0x01
+	PowerStatusStandbyToOn PowerStatus = 0x02
+	PowerStatusOnToStandby PowerStatus = 0x03
+	PowerStatusUnknown     PowerStatus = 0x99 // no answer (libcec)
+)
+
+var powerStatusNames = map[PowerStatus]string{PowerStatusOn: "on",
+	PowerStatusStandby: "standby", PowerStatusStandbyToOn: "starting",
+	PowerStatusOnToStandby: "shutting down", PowerStatusUnknown: "unknown"}
+
+// IsValid - whether the power status is known
+func (s PowerStatus) IsValid() bool {
+	_, ok := powerStatusNames[s]
+	return ok
+}
+
+func (s PowerStatus) String() string {
+	if name, ok := powerStatusNames[s]; ok {
+		return name
+	}
+	return "Unknown"
+}
+
+// MarshalText - the name of the power status ("standby")
+func (s PowerStatus) MarshalText() ([]byte, error) {
+	if !s.IsValid() {
+		return nil, fmt.Errorf("cec: invalid power status %d", s)
+	}
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText - accepts the names of String or the number
+func (s *PowerStatus) UnmarshalText(text []byte) error {
+	name := normalizeName(string(text))
+	for status, n := range powerStatusNames {
+		if normalizeName(n) == name {
+			*s = status
+			return nil
+		}
+	}
+	if n, ok := parseNumber(string(text), 0xFF); ok && PowerStatus(n).IsValid() {
+		*s = PowerStatus(n)
+		return nil
+	}
+	return fmt.Errorf("cec: unknown power status %q", text)
+}
+
+// normalizeName - lower case without separators, for comparing names
+func normalizeName(name string) string {
+	return strings.ToLower(removeSeparators(name))
+}
+
+// parseNumber - parse a decimal or 0x prefixed hex number up to max
+func parseNumber(s string, max int) (int, bool) {
+	n, err := strconv.ParseUint(s, 0, 8)
+	if err != nil || int(n) > max {
+		return 0, false
+	}
+	return int(n), true
+}
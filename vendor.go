@@ -0,0 +1,140 @@
+package cec
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// vendor IDs (IEEE OUI) of TV manufacturers with vendor specific commands
+const (
+	VendorSamsung   uint32 = 0x0000F0 // Anynet+
+	VendorLG        uint32 = 0x00E091 // SimpLink
+	VendorSony      uint32 = 0x080046 // Bravia Sync
+	VendorPanasonic uint32 = 0x008045 // VIERA Link
+	VendorPhilips   uint32 = 0x00903E // EasyLink
+	VendorToshiba   uint32 = 0x000039 // Regza Link
+)
+
+// VendorHandler - decodes and builds the vendor specific messages of a
+// manufacturer (Vendor Command, Vendor Command With ID, Vendor Remote
+// Button Down and Up)
+type VendorHandler interface {
+	// DecodeVendor - the payload of a received vendor message, nil if the
+	// handler does not know the message
+	DecodeVendor(msg Message) (interface{}, error)
+	// EncodeVendor - the vendor message for a payload
+	EncodeVendor(payload interface{}) (Message, error)
+}
+
+var vendorHandlers = struct {
+	sync.RWMutex
+	m map[uint32]VendorHandler
+}{m: make(map[uint32]VendorHandler)}
+
+// RegisterVendorHandler - use the handler for the vendor messages of
+// devices with the given 24 bit vendor ID (as reported by
+// GetDeviceVendorID), a nil handler removes it. Handlers are shared by all
+// connections.
+func RegisterVendorHandler(vendorID uint32, handler VendorHandler) {
+	vendorHandlers.Lock()
+	defer vendorHandlers.Unlock()
+
+	if handler == nil {
+		delete(vendorHandlers.m, vendorID)
+		return
+	}
+	vendorHandlers.m[vendorID] = handler
+}
+
+func vendorHandler(vendorID uint32) VendorHandler {
+	vendorHandlers.RLock()
+	defer vendorHandlers.RUnlock()
+
+	return vendorHandlers.m[vendorID]
+}
+
+// VendorCommand - send vendor specific data (1-14 bytes)
+func (c *Connection) VendorCommand(address LogicalAddress, data []byte) error {
+	return c.Send(address, VendorCommand{Data: data})
+}
+
+// VendorCommandWithID - send vendor specific data (1-11 bytes) defined by
+// the given vendor, usually broadcast
+func (c *Connection) VendorCommandWithID(address LogicalAddress, vendorID uint32, data []byte) error {
+	return c.Send(address, VendorCommandWithID{VendorID: vendorID, Data: data})
+}
+
+// VendorRemoteButtonDown - press a vendor specific remote key (1-14 bytes
+// of key code)
+func (c *Connection) VendorRemoteButtonDown(address LogicalAddress, code []byte) error {
+	return c.Send(address, VendorRemoteButtonDown{Code: code})
+}
+
+// VendorRemoteButtonUp - release the vendor specific remote key
+func (c *Connection) VendorRemoteButtonUp(address LogicalAddress) error {
+	return c.Send(address, VendorRemoteButtonUp{})
+}
+
+// SendVendor - send a payload encoded by the VendorHandler of the vendor
+// of the device (of the TV for Broadcast)
+func (c *Connection) SendVendor(ctx context.Context, address LogicalAddress, payload interface{}) error {
+	device := address
+	if address == Broadcast {
+		device = TV
+	}
+	dev, err := c.registry.Device(ctx, device)
+	if err != nil {
+		return err
+	}
+	handler := vendorHandler(uint32(dev.VendorID))
+	if handler == nil {
+		return fmt.Errorf("cec: no vendor handler for %s (0x%06x)", GetVendorString(dev.VendorID), dev.VendorID)
+	}
+
+	msg, err := handler.EncodeVendor(payload)
+	if err != nil {
+		return err
+	}
+	if !isVendorOpcode(msg.Opcode()) {
+		return fmt.Errorf("cec: vendor handler built %s, not a vendor message", msg.Opcode())
+	}
+	return c.SendContext(ctx, address, msg)
+}
+
+// OnVendorCommand - call fn for every vendor message received, with the
+// payload decoded by the VendorHandler of the initiator in Command.Vendor
+func (c *Connection) OnVendorCommand(fn func(Command)) *Subscription {
+	return c.OnCommand(fn, OpVendorCommand, OpVendorCommandWithID,
+		OpVendorRemoteButtonDown, OpVendorRemoteButtonUp)
+}
+
+func isVendorOpcode(opcode Opcode) bool {
+	switch opcode {
+	case OpVendorCommand, OpVendorCommandWithID, OpVendorRemoteButtonDown, OpVendorRemoteButtonUp:
+		return true
+	}
+	return false
+}
+
+// decodeVendor - add the vendor ID of the initiator and the payload
+// decoded by its handler to vendor messages. The vendor ID is the one of
+// Vendor Command With ID, or the cached one of the initiator.
+func (c *Connection) decodeVendor(event interface{}) interface{} {
+	cmd, ok := event.(Command)
+	if !ok || cmd.Message == nil || !isVendorOpcode(cmd.Opcode) {
+		return event
+	}
+
+	if m, ok := cmd.Message.(VendorCommandWithID); ok {
+		cmd.VendorID = m.VendorID
+	} else if dev, ok := c.registry.Cached(cmd.Initiator); ok {
+		cmd.VendorID = uint32(dev.VendorID)
+	}
+	if handler := vendorHandler(cmd.VendorID); handler != nil {
+		if payload, err := handler.DecodeVendor(cmd.Message); err == nil {
+			cmd.Vendor = payload
+		}
+	}
+	return cmd
+}
@@ -0,0 +1,73 @@
+package cec_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/chbmuc/cec"
+	"github.com/chbmuc/cec/cectest"
+)
+
+// echo - a vendor handler with the data of the vendor commands as payload
+type echo struct{}
+
+func (echo) DecodeVendor(msg cec.Message) (interface{}, error) {
+	switch m := msg.(type) {
+	case cec.VendorCommand:
+		return string(m.Data), nil
+	case cec.VendorCommandWithID:
+		return string(m.Data), nil
+	}
+	return nil, nil
+}
+
+func (echo) EncodeVendor(payload interface{}) (cec.Message, error) {
+	return cec.VendorCommand{Data: []byte(payload.(string))}, nil
+}
+
+func TestVendorCommandWithID(t *testing.T) {
+	c, bus := openBus(t, cectest.NewTV())
+
+	if err := c.VendorCommandWithID(cec.Broadcast, cec.VendorSamsung, []byte{0x23}); err != nil {
+		t.Fatalf("VendorCommandWithID: %v", err)
+	}
+	want := []byte{0x4F, 0xA0, 0x00, 0x00, 0xF0, 0x23}
+	frames := bus.Frames()
+	if last := frames[len(frames)-1]; !bytes.Equal(last, want) {
+		t.Errorf("sent % x, want % x", last, want)
+	}
+
+	if err := c.VendorCommandWithID(cec.Broadcast, 0x1000000, []byte{0x23}); !errors.Is(err, cec.ErrInvalidFrame) {
+		t.Errorf("VendorCommandWithID with a 32 bit vendor ID = %v, want ErrInvalidFrame", err)
+	}
+}
+
+func TestOnVendorCommand(t *testing.T) {
+	cec.RegisterVendorHandler(cec.VendorSamsung, echo{})
+	defer cec.RegisterVendorHandler(cec.VendorSamsung, nil)
+
+	c, bus := openBus(t, cectest.NewTV())
+	events := collect(t, c)
+
+	// Vendor Command With ID from the (Samsung) TV, decoded by the handler
+	// registered for its vendor ID
+	bus.Transmit([]byte{0x0F, 0xA0, 0x00, 0x00, 0xF0, 'h', 'i'})
+	events.wait(t, "vendor Command", func(event interface{}) bool {
+		cmd, ok := event.(cec.Command)
+		return ok && cmd.VendorID == cec.VendorSamsung && cmd.Vendor == "hi"
+	})
+
+	// encoded by the handler of the TV's vendor
+	if err := c.SendVendor(context.Background(), cec.TV, "hi"); err != nil {
+		t.Fatalf("SendVendor: %v", err)
+	}
+	want := []byte{0x40, 0x89, 'h', 'i'}
+	for _, f := range bus.Frames() {
+		if bytes.Equal(f, want) {
+			return
+		}
+	}
+	t.Errorf("no % x in % x", want, bus.Frames())
+}